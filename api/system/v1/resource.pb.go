@@ -0,0 +1,845 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: system/v1/resource.proto
+
+package v1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 事件类型
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	EventType_ADDED                  EventType = 1
+	EventType_MODIFIED               EventType = 2
+	EventType_DELETED                EventType = 3
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "ADDED",
+		2: "MODIFIED",
+		3: "DELETED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"ADDED":                  1,
+		"MODIFIED":               2,
+		"DELETED":                3,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_system_v1_resource_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_system_v1_resource_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{0}
+}
+
+type ResourceConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApiVersion    string                 `protobuf:"bytes,1,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	Metadata      *Metadata              `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Spec          string                 `protobuf:"bytes,4,opt,name=spec,proto3" json:"spec,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceConfig) Reset() {
+	*x = ResourceConfig{}
+	mi := &file_system_v1_resource_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceConfig) ProtoMessage() {}
+
+func (x *ResourceConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceConfig.ProtoReflect.Descriptor instead.
+func (*ResourceConfig) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResourceConfig) GetApiVersion() string {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return ""
+}
+
+func (x *ResourceConfig) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ResourceConfig) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ResourceConfig) GetSpec() string {
+	if x != nil {
+		return x.Spec
+	}
+	return ""
+}
+
+type Metadata struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ResourceVersion string                 `protobuf:"bytes,2,opt,name=resource_version,json=resourceVersion,proto3" json:"resource_version,omitempty"`
+	Generation      int32                  `protobuf:"varint,3,opt,name=generation,proto3" json:"generation,omitempty"`
+	CreationTime    string                 `protobuf:"bytes,4,opt,name=creation_time,json=creationTime,proto3" json:"creation_time,omitempty"`
+	DeletionTime    string                 `protobuf:"bytes,5,opt,name=deletion_time,json=deletionTime,proto3" json:"deletion_time,omitempty"`
+	Labels          map[string]string      `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Annotations     map[string]string      `protobuf:"bytes,7,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_system_v1_resource_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Metadata) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Metadata) GetResourceVersion() string {
+	if x != nil {
+		return x.ResourceVersion
+	}
+	return ""
+}
+
+func (x *Metadata) GetGeneration() int32 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *Metadata) GetCreationTime() string {
+	if x != nil {
+		return x.CreationTime
+	}
+	return ""
+}
+
+func (x *Metadata) GetDeletionTime() string {
+	if x != nil {
+		return x.DeletionTime
+	}
+	return ""
+}
+
+func (x *Metadata) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Metadata) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+type ResourceStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 当前阶段
+	Phase string `protobuf:"bytes,1,opt,name=phase,proto3" json:"phase,omitempty"`
+	// 原因
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// 消息
+	Message string `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	// 最后同步时间
+	LastReconcileTime *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_reconcile_time,json=lastReconcileTime,proto3" json:"last_reconcile_time,omitempty"`
+	// 最近一次调谐的配置代数，等于 metadata.generation 时表示最新的配置已被处理
+	ObservedGeneration int32 `protobuf:"varint,5,opt,name=observed_generation,json=observedGeneration,proto3" json:"observed_generation,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ResourceStatus) Reset() {
+	*x = ResourceStatus{}
+	mi := &file_system_v1_resource_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceStatus) ProtoMessage() {}
+
+func (x *ResourceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceStatus.ProtoReflect.Descriptor instead.
+func (*ResourceStatus) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResourceStatus) GetPhase() string {
+	if x != nil {
+		return x.Phase
+	}
+	return ""
+}
+
+func (x *ResourceStatus) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ResourceStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ResourceStatus) GetLastReconcileTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastReconcileTime
+	}
+	return nil
+}
+
+func (x *ResourceStatus) GetObservedGeneration() int32 {
+	if x != nil {
+		return x.ObservedGeneration
+	}
+	return 0
+}
+
+type Resource struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Config          *ResourceConfig        `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	EffectiveConfig *ResourceConfig        `protobuf:"bytes,2,opt,name=effective_config,json=effectiveConfig,proto3" json:"effective_config,omitempty"`
+	Status          *ResourceStatus        `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Resource) Reset() {
+	*x = Resource{}
+	mi := &file_system_v1_resource_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Resource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Resource) ProtoMessage() {}
+
+func (x *Resource) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Resource.ProtoReflect.Descriptor instead.
+func (*Resource) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Resource) GetConfig() *ResourceConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+func (x *Resource) GetEffectiveConfig() *ResourceConfig {
+	if x != nil {
+		return x.EffectiveConfig
+	}
+	return nil
+}
+
+func (x *Resource) GetStatus() *ResourceStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type ListResourceConfigsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResourceConfigsRequest) Reset() {
+	*x = ListResourceConfigsRequest{}
+	mi := &file_system_v1_resource_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResourceConfigsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResourceConfigsRequest) ProtoMessage() {}
+
+func (x *ListResourceConfigsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResourceConfigsRequest.ProtoReflect.Descriptor instead.
+func (*ListResourceConfigsRequest) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListResourceConfigsRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type ListResourceConfigsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Resources     []*Resource            `protobuf:"bytes,1,rep,name=resources,proto3" json:"resources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResourceConfigsResponse) Reset() {
+	*x = ListResourceConfigsResponse{}
+	mi := &file_system_v1_resource_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResourceConfigsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResourceConfigsResponse) ProtoMessage() {}
+
+func (x *ListResourceConfigsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResourceConfigsResponse.ProtoReflect.Descriptor instead.
+func (*ListResourceConfigsResponse) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListResourceConfigsResponse) GetResources() []*Resource {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+type GetResourceConfigRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// 不同类型的资源同名时需要指定类型
+	Kind          string `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResourceConfigRequest) Reset() {
+	*x = GetResourceConfigRequest{}
+	mi := &file_system_v1_resource_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResourceConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResourceConfigRequest) ProtoMessage() {}
+
+func (x *GetResourceConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResourceConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetResourceConfigRequest) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetResourceConfigRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetResourceConfigRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type UpdateResourceConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Resource      *Resource              `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateResourceConfigRequest) Reset() {
+	*x = UpdateResourceConfigRequest{}
+	mi := &file_system_v1_resource_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateResourceConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateResourceConfigRequest) ProtoMessage() {}
+
+func (x *UpdateResourceConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateResourceConfigRequest.ProtoReflect.Descriptor instead.
+func (*UpdateResourceConfigRequest) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateResourceConfigRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateResourceConfigRequest) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+type WatchResourceConfigsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 只监听该类型的资源
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// 只监听该名称的资源
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// 从该序号之后的事件开始监听，为 0 时先以 ADDED 事件返回所有资源
+	Since         uint64 `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResourceConfigsRequest) Reset() {
+	*x = WatchResourceConfigsRequest{}
+	mi := &file_system_v1_resource_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResourceConfigsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResourceConfigsRequest) ProtoMessage() {}
+
+func (x *WatchResourceConfigsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResourceConfigsRequest.ProtoReflect.Descriptor instead.
+func (*WatchResourceConfigsRequest) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchResourceConfigsRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *WatchResourceConfigsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WatchResourceConfigsRequest) GetSince() uint64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+type WatchEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=xtopus.api.system.v1.EventType" json:"type,omitempty"`
+	// 变化后的资源，DELETED 事件中只有被删除的配置
+	Resource *Resource `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	// 事件序号，重新监听时作为 since 以继续接收之后的事件
+	Sequence      uint64 `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_system_v1_resource_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_system_v1_resource_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_system_v1_resource_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *WatchEvent) GetResource() *Resource {
+	if x != nil {
+		return x.Resource
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+var File_system_v1_resource_proto protoreflect.FileDescriptor
+
+const file_system_v1_resource_proto_rawDesc = "" +
+	"\n" +
+	"\x18system/v1/resource.proto\x12\x14xtopus.api.system.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1cgoogle/api/annotations.proto\"\x95\x01\n" +
+	"\x0eResourceConfig\x12\x1f\n" +
+	"\vapi_version\x18\x01 \x01(\tR\n" +
+	"apiVersion\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12:\n" +
+	"\bmetadata\x18\x03 \x01(\v2\x1e.xtopus.api.system.v1.MetadataR\bmetadata\x12\x12\n" +
+	"\x04spec\x18\x04 \x01(\tR\x04spec\"\xc5\x03\n" +
+	"\bMetadata\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12)\n" +
+	"\x10resource_version\x18\x02 \x01(\tR\x0fresourceVersion\x12\x1e\n" +
+	"\n" +
+	"generation\x18\x03 \x01(\x05R\n" +
+	"generation\x12#\n" +
+	"\rcreation_time\x18\x04 \x01(\tR\fcreationTime\x12#\n" +
+	"\rdeletion_time\x18\x05 \x01(\tR\fdeletionTime\x12B\n" +
+	"\x06labels\x18\x06 \x03(\v2*.xtopus.api.system.v1.Metadata.LabelsEntryR\x06labels\x12Q\n" +
+	"\vannotations\x18\a \x03(\v2/.xtopus.api.system.v1.Metadata.AnnotationsEntryR\vannotations\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a>\n" +
+	"\x10AnnotationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd5\x01\n" +
+	"\x0eResourceStatus\x12\x14\n" +
+	"\x05phase\x18\x01 \x01(\tR\x05phase\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12J\n" +
+	"\x13last_reconcile_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x11lastReconcileTime\x12/\n" +
+	"\x13observed_generation\x18\x05 \x01(\x05R\x12observedGeneration\"\xd7\x01\n" +
+	"\bResource\x12<\n" +
+	"\x06config\x18\x01 \x01(\v2$.xtopus.api.system.v1.ResourceConfigR\x06config\x12O\n" +
+	"\x10effective_config\x18\x02 \x01(\v2$.xtopus.api.system.v1.ResourceConfigR\x0feffectiveConfig\x12<\n" +
+	"\x06status\x18\x03 \x01(\v2$.xtopus.api.system.v1.ResourceStatusR\x06status\"0\n" +
+	"\x1aListResourceConfigsRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\"[\n" +
+	"\x1bListResourceConfigsResponse\x12<\n" +
+	"\tresources\x18\x01 \x03(\v2\x1e.xtopus.api.system.v1.ResourceR\tresources\"B\n" +
+	"\x18GetResourceConfigRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\"m\n" +
+	"\x1bUpdateResourceConfigRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12:\n" +
+	"\bresource\x18\x02 \x01(\v2\x1e.xtopus.api.system.v1.ResourceR\bresource\"[\n" +
+	"\x1bWatchResourceConfigsRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05since\x18\x03 \x01(\x04R\x05since\"\x99\x01\n" +
+	"\n" +
+	"WatchEvent\x123\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1f.xtopus.api.system.v1.EventTypeR\x04type\x12:\n" +
+	"\bresource\x18\x02 \x01(\v2\x1e.xtopus.api.system.v1.ResourceR\bresource\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence*M\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\t\n" +
+	"\x05ADDED\x10\x01\x12\f\n" +
+	"\bMODIFIED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x032\xc9\x04\n" +
+	"\x15HardwareConfigService\x12\x91\x01\n" +
+	"\x13ListResourceConfigs\x120.xtopus.api.system.v1.ListResourceConfigsRequest\x1a1.xtopus.api.system.v1.ListResourceConfigsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/v1/resources\x12\x81\x01\n" +
+	"\x11GetResourceConfig\x12..xtopus.api.system.v1.GetResourceConfigRequest\x1a\x1e.xtopus.api.system.v1.Resource\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/v1/resources/{name}\x12\x8a\x01\n" +
+	"\x14UpdateResourceConfig\x121.xtopus.api.system.v1.UpdateResourceConfigRequest\x1a\x1e.xtopus.api.system.v1.Resource\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\x1a\x14/v1/resources/{name}\x12\x8a\x01\n" +
+	"\x14WatchResourceConfigs\x121.xtopus.api.system.v1.WatchResourceConfigsRequest\x1a .xtopus.api.system.v1.WatchEvent\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/v1/resources:watch0\x01B,Z*go.xbrother.com/nix-operator/api/system/v1b\x06proto3"
+
+var (
+	file_system_v1_resource_proto_rawDescOnce sync.Once
+	file_system_v1_resource_proto_rawDescData []byte
+)
+
+func file_system_v1_resource_proto_rawDescGZIP() []byte {
+	file_system_v1_resource_proto_rawDescOnce.Do(func() {
+		file_system_v1_resource_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_system_v1_resource_proto_rawDesc), len(file_system_v1_resource_proto_rawDesc)))
+	})
+	return file_system_v1_resource_proto_rawDescData
+}
+
+var file_system_v1_resource_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_system_v1_resource_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_system_v1_resource_proto_goTypes = []any{
+	(EventType)(0),                      // 0: xtopus.api.system.v1.EventType
+	(*ResourceConfig)(nil),              // 1: xtopus.api.system.v1.ResourceConfig
+	(*Metadata)(nil),                    // 2: xtopus.api.system.v1.Metadata
+	(*ResourceStatus)(nil),              // 3: xtopus.api.system.v1.ResourceStatus
+	(*Resource)(nil),                    // 4: xtopus.api.system.v1.Resource
+	(*ListResourceConfigsRequest)(nil),  // 5: xtopus.api.system.v1.ListResourceConfigsRequest
+	(*ListResourceConfigsResponse)(nil), // 6: xtopus.api.system.v1.ListResourceConfigsResponse
+	(*GetResourceConfigRequest)(nil),    // 7: xtopus.api.system.v1.GetResourceConfigRequest
+	(*UpdateResourceConfigRequest)(nil), // 8: xtopus.api.system.v1.UpdateResourceConfigRequest
+	(*WatchResourceConfigsRequest)(nil), // 9: xtopus.api.system.v1.WatchResourceConfigsRequest
+	(*WatchEvent)(nil),                  // 10: xtopus.api.system.v1.WatchEvent
+	nil,                                 // 11: xtopus.api.system.v1.Metadata.LabelsEntry
+	nil,                                 // 12: xtopus.api.system.v1.Metadata.AnnotationsEntry
+	(*timestamppb.Timestamp)(nil),       // 13: google.protobuf.Timestamp
+}
+var file_system_v1_resource_proto_depIdxs = []int32{
+	2,  // 0: xtopus.api.system.v1.ResourceConfig.metadata:type_name -> xtopus.api.system.v1.Metadata
+	11, // 1: xtopus.api.system.v1.Metadata.labels:type_name -> xtopus.api.system.v1.Metadata.LabelsEntry
+	12, // 2: xtopus.api.system.v1.Metadata.annotations:type_name -> xtopus.api.system.v1.Metadata.AnnotationsEntry
+	13, // 3: xtopus.api.system.v1.ResourceStatus.last_reconcile_time:type_name -> google.protobuf.Timestamp
+	1,  // 4: xtopus.api.system.v1.Resource.config:type_name -> xtopus.api.system.v1.ResourceConfig
+	1,  // 5: xtopus.api.system.v1.Resource.effective_config:type_name -> xtopus.api.system.v1.ResourceConfig
+	3,  // 6: xtopus.api.system.v1.Resource.status:type_name -> xtopus.api.system.v1.ResourceStatus
+	4,  // 7: xtopus.api.system.v1.ListResourceConfigsResponse.resources:type_name -> xtopus.api.system.v1.Resource
+	4,  // 8: xtopus.api.system.v1.UpdateResourceConfigRequest.resource:type_name -> xtopus.api.system.v1.Resource
+	0,  // 9: xtopus.api.system.v1.WatchEvent.type:type_name -> xtopus.api.system.v1.EventType
+	4,  // 10: xtopus.api.system.v1.WatchEvent.resource:type_name -> xtopus.api.system.v1.Resource
+	5,  // 11: xtopus.api.system.v1.HardwareConfigService.ListResourceConfigs:input_type -> xtopus.api.system.v1.ListResourceConfigsRequest
+	7,  // 12: xtopus.api.system.v1.HardwareConfigService.GetResourceConfig:input_type -> xtopus.api.system.v1.GetResourceConfigRequest
+	8,  // 13: xtopus.api.system.v1.HardwareConfigService.UpdateResourceConfig:input_type -> xtopus.api.system.v1.UpdateResourceConfigRequest
+	9,  // 14: xtopus.api.system.v1.HardwareConfigService.WatchResourceConfigs:input_type -> xtopus.api.system.v1.WatchResourceConfigsRequest
+	6,  // 15: xtopus.api.system.v1.HardwareConfigService.ListResourceConfigs:output_type -> xtopus.api.system.v1.ListResourceConfigsResponse
+	4,  // 16: xtopus.api.system.v1.HardwareConfigService.GetResourceConfig:output_type -> xtopus.api.system.v1.Resource
+	4,  // 17: xtopus.api.system.v1.HardwareConfigService.UpdateResourceConfig:output_type -> xtopus.api.system.v1.Resource
+	10, // 18: xtopus.api.system.v1.HardwareConfigService.WatchResourceConfigs:output_type -> xtopus.api.system.v1.WatchEvent
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_system_v1_resource_proto_init() }
+func file_system_v1_resource_proto_init() {
+	if File_system_v1_resource_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_system_v1_resource_proto_rawDesc), len(file_system_v1_resource_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_system_v1_resource_proto_goTypes,
+		DependencyIndexes: file_system_v1_resource_proto_depIdxs,
+		EnumInfos:         file_system_v1_resource_proto_enumTypes,
+		MessageInfos:      file_system_v1_resource_proto_msgTypes,
+	}.Build()
+	File_system_v1_resource_proto = out.File
+	file_system_v1_resource_proto_goTypes = nil
+	file_system_v1_resource_proto_depIdxs = nil
+}
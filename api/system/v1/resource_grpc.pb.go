@@ -0,0 +1,251 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: system/v1/resource.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	HardwareConfigService_ListResourceConfigs_FullMethodName  = "/xtopus.api.system.v1.HardwareConfigService/ListResourceConfigs"
+	HardwareConfigService_GetResourceConfig_FullMethodName    = "/xtopus.api.system.v1.HardwareConfigService/GetResourceConfig"
+	HardwareConfigService_UpdateResourceConfig_FullMethodName = "/xtopus.api.system.v1.HardwareConfigService/UpdateResourceConfig"
+	HardwareConfigService_WatchResourceConfigs_FullMethodName = "/xtopus.api.system.v1.HardwareConfigService/WatchResourceConfigs"
+)
+
+// HardwareConfigServiceClient is the client API for HardwareConfigService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 硬件配置服务
+type HardwareConfigServiceClient interface {
+	// 获取所有资源配置
+	ListResourceConfigs(ctx context.Context, in *ListResourceConfigsRequest, opts ...grpc.CallOption) (*ListResourceConfigsResponse, error)
+	// 获取资源配置
+	GetResourceConfig(ctx context.Context, in *GetResourceConfigRequest, opts ...grpc.CallOption) (*Resource, error)
+	// 更新资源配置
+	UpdateResourceConfig(ctx context.Context, in *UpdateResourceConfigRequest, opts ...grpc.CallOption) (*Resource, error)
+	// 监听资源配置和状态的变化
+	WatchResourceConfigs(ctx context.Context, in *WatchResourceConfigsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+}
+
+type hardwareConfigServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHardwareConfigServiceClient(cc grpc.ClientConnInterface) HardwareConfigServiceClient {
+	return &hardwareConfigServiceClient{cc}
+}
+
+func (c *hardwareConfigServiceClient) ListResourceConfigs(ctx context.Context, in *ListResourceConfigsRequest, opts ...grpc.CallOption) (*ListResourceConfigsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResourceConfigsResponse)
+	err := c.cc.Invoke(ctx, HardwareConfigService_ListResourceConfigs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hardwareConfigServiceClient) GetResourceConfig(ctx context.Context, in *GetResourceConfigRequest, opts ...grpc.CallOption) (*Resource, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, HardwareConfigService_GetResourceConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hardwareConfigServiceClient) UpdateResourceConfig(ctx context.Context, in *UpdateResourceConfigRequest, opts ...grpc.CallOption) (*Resource, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Resource)
+	err := c.cc.Invoke(ctx, HardwareConfigService_UpdateResourceConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hardwareConfigServiceClient) WatchResourceConfigs(ctx context.Context, in *WatchResourceConfigsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &HardwareConfigService_ServiceDesc.Streams[0], HardwareConfigService_WatchResourceConfigs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchResourceConfigsRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HardwareConfigService_WatchResourceConfigsClient = grpc.ServerStreamingClient[WatchEvent]
+
+// HardwareConfigServiceServer is the server API for HardwareConfigService service.
+// All implementations must embed UnimplementedHardwareConfigServiceServer
+// for forward compatibility.
+//
+// 硬件配置服务
+type HardwareConfigServiceServer interface {
+	// 获取所有资源配置
+	ListResourceConfigs(context.Context, *ListResourceConfigsRequest) (*ListResourceConfigsResponse, error)
+	// 获取资源配置
+	GetResourceConfig(context.Context, *GetResourceConfigRequest) (*Resource, error)
+	// 更新资源配置
+	UpdateResourceConfig(context.Context, *UpdateResourceConfigRequest) (*Resource, error)
+	// 监听资源配置和状态的变化
+	WatchResourceConfigs(*WatchResourceConfigsRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	mustEmbedUnimplementedHardwareConfigServiceServer()
+}
+
+// UnimplementedHardwareConfigServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHardwareConfigServiceServer struct{}
+
+func (UnimplementedHardwareConfigServiceServer) ListResourceConfigs(context.Context, *ListResourceConfigsRequest) (*ListResourceConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResourceConfigs not implemented")
+}
+func (UnimplementedHardwareConfigServiceServer) GetResourceConfig(context.Context, *GetResourceConfigRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResourceConfig not implemented")
+}
+func (UnimplementedHardwareConfigServiceServer) UpdateResourceConfig(context.Context, *UpdateResourceConfigRequest) (*Resource, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateResourceConfig not implemented")
+}
+func (UnimplementedHardwareConfigServiceServer) WatchResourceConfigs(*WatchResourceConfigsRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchResourceConfigs not implemented")
+}
+func (UnimplementedHardwareConfigServiceServer) mustEmbedUnimplementedHardwareConfigServiceServer() {}
+func (UnimplementedHardwareConfigServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeHardwareConfigServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HardwareConfigServiceServer will
+// result in compilation errors.
+type UnsafeHardwareConfigServiceServer interface {
+	mustEmbedUnimplementedHardwareConfigServiceServer()
+}
+
+func RegisterHardwareConfigServiceServer(s grpc.ServiceRegistrar, srv HardwareConfigServiceServer) {
+	// If the following call pancis, it indicates UnimplementedHardwareConfigServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&HardwareConfigService_ServiceDesc, srv)
+}
+
+func _HardwareConfigService_ListResourceConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResourceConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HardwareConfigServiceServer).ListResourceConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HardwareConfigService_ListResourceConfigs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HardwareConfigServiceServer).ListResourceConfigs(ctx, req.(*ListResourceConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HardwareConfigService_GetResourceConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResourceConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HardwareConfigServiceServer).GetResourceConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HardwareConfigService_GetResourceConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HardwareConfigServiceServer).GetResourceConfig(ctx, req.(*GetResourceConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HardwareConfigService_UpdateResourceConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateResourceConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HardwareConfigServiceServer).UpdateResourceConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HardwareConfigService_UpdateResourceConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HardwareConfigServiceServer).UpdateResourceConfig(ctx, req.(*UpdateResourceConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HardwareConfigService_WatchResourceConfigs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchResourceConfigsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HardwareConfigServiceServer).WatchResourceConfigs(m, &grpc.GenericServerStream[WatchResourceConfigsRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HardwareConfigService_WatchResourceConfigsServer = grpc.ServerStreamingServer[WatchEvent]
+
+// HardwareConfigService_ServiceDesc is the grpc.ServiceDesc for HardwareConfigService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HardwareConfigService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "xtopus.api.system.v1.HardwareConfigService",
+	HandlerType: (*HardwareConfigServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListResourceConfigs",
+			Handler:    _HardwareConfigService_ListResourceConfigs_Handler,
+		},
+		{
+			MethodName: "GetResourceConfig",
+			Handler:    _HardwareConfigService_GetResourceConfig_Handler,
+		},
+		{
+			MethodName: "UpdateResourceConfig",
+			Handler:    _HardwareConfigService_UpdateResourceConfig_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchResourceConfigs",
+			Handler:       _HardwareConfigService_WatchResourceConfigs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "system/v1/resource.proto",
+}
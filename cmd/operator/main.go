@@ -1,10 +1,30 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/apiserver"
 	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/schema"
+	"go.xbrother.com/nix-operator/pkg/utils"
 
 	// 注册所有处理器
 	_ "go.xbrother.com/nix-operator/pkg/handlers/hosts"
@@ -14,17 +34,228 @@ import (
 	_ "go.xbrother.com/nix-operator/pkg/handlers/udev"
 )
 
+const usage = `Usage: %s [flags] [command]
+
+Commands:
+  run   Reconcile resources and watch the configuration directory (default)
+  plan  Show the file diffs and commands each resource would produce, without changing the system
+  schema [kind]
+        Print the JSON Schema of a resource kind, or of all kinds keyed by kind
+
+Flags:
+`
+
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), usage, os.Args[0])
+		flag.PrintDefaults()
+	}
 	configDir := flag.String("config-dir", "etc/cr.d", "Path to configuration directory")
+	root := flag.String("root", "/", "Root directory of the managed system; only file reads and writes are redirected below it, commands such as systemctl and netplan still run on the live host")
+	statusDir := flag.String("status-dir", "", "Path to resource status directory (default: <config-dir>/.status)")
+	debounce := flag.Duration("debounce", controller.DefaultDebounce, "Time to wait for a burst of file events to settle before reconciling")
+	retryBase := flag.Duration("retry-base", controller.DefaultRetryBase, "Initial delay before retrying a failed reconcile")
+	retryMax := flag.Duration("retry-max", controller.DefaultRetryMax, "Maximum delay between retries of a failed reconcile")
+	maxRetries := flag.Int("max-retries", controller.DefaultMaxRetries, "Number of retries before giving up on a failed reconcile")
+	resyncPeriod := flag.Duration("resync-period", controller.DefaultResyncPeriod, "Interval of drift detection against the live system, 0 to disable")
+	reconcileTimeout := flag.Duration("reconcile-timeout", controller.DefaultReconcileTimeout, "Maximum duration of reconciling or cleaning up a single resource, 0 to disable")
+	shutdownTimeout := flag.Duration("shutdown-timeout", controller.DefaultDrainTimeout, "Time to wait for in-flight reconciles and API requests on SIGINT/SIGTERM before cancelling them")
+	apiAddr := flag.String("api-addr", "127.0.0.1:8081", "Listen address of the HTTP API, empty to disable")
+	grpcAddr := flag.String("grpc-addr", "127.0.0.1:8082", "Listen address of the gRPC API, empty to disable")
+	lang := flag.String("lang", schema.LangZH, "Language of schema descriptions: zh or en")
+	logFormat := flag.String("log-format", utils.LogFormatText, "Log output format: text or json")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
 	flag.Parse()
 
-	controller, err := controller.NewController(*configDir)
+	logger, err := utils.NewLogger(os.Stderr, *logFormat, *logLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	// log 包的输出也以 info 级别写入结构化日志
+	slog.SetDefault(logger)
+
+	command := flag.Arg(0)
+	switch command {
+	case "", "run", "plan":
+	case "schema":
+		if err := printSchema(os.Stdout, flag.Arg(1), *lang); err != nil {
+			fatal("Failed to generate schema", err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []controller.Option{
+		controller.WithDebounce(*debounce),
+		controller.WithRetry(*retryBase, *retryMax, *maxRetries),
+		controller.WithResyncPeriod(*resyncPeriod),
+		controller.WithReconcileTimeout(*reconcileTimeout),
+		controller.WithDrainTimeout(*shutdownTimeout),
+		controller.WithNotifier(utils.NotifierFromEnv()),
+		controller.WithWatchdog(utils.WatchdogInterval()),
+		controller.WithRoot(*root),
+	}
+	if *statusDir != "" {
+		opts = append(opts, controller.WithStatusDir(*statusDir))
+	}
+	if command == "plan" {
+		// 预览不修改状态目录，也不迁移旧版本的状态文件
+		opts = append(opts, controller.WithReadOnlyStatus())
+	}
+
+	c, err := controller.NewController(*configDir, opts...)
+	if err != nil {
+		fatal("Failed to create controller", err)
+	}
+
+	// 收到 SIGINT 或 SIGTERM 时优雅退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "", "run":
+		slog.Info("Starting nix-operator", "configDir", *configDir, "root", *root)
+		if filepath.Clean(*root) != "/" {
+			slog.Warn("Commands run on the live host, only files are written below the root", "root", *root)
+		}
+		resources, system := apiserver.NewResourceService(c), apiserver.NewSystemService(c)
+		var wg sync.WaitGroup
+		if *apiAddr != "" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serveAPI(ctx, *apiAddr, *shutdownTimeout, resources, system)
+			}()
+		}
+		if *grpcAddr != "" {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serveGRPC(ctx, *grpcAddr, *shutdownTimeout, resources, system)
+			}()
+		}
+		if err := c.Run(ctx); err != nil {
+			fatal("Failed to run controller", err)
+		}
+		wg.Wait()
+		slog.Info("nix-operator stopped")
+	case "plan":
+		plans, err := c.Plan(ctx)
+		if err != nil {
+			fatal("Failed to plan changes", err)
+		}
+		printPlans(os.Stdout, plans)
+	}
+}
+
+// serveAPI 在 addr 上提供 HTTP API，ctx 结束后停止接受请求，
+// 进行中的请求（包括监听）随 ctx 取消，最多等待 timeout
+func serveAPI(ctx context.Context, addr string, timeout time.Duration, resources *apiserver.ResourceService, system *apiserver.SystemService) {
+	server := &http.Server{
+		Addr:        addr,
+		Handler:     apiserver.NewServer(resources, system),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down API", "error", err)
+		}
+	}()
+
+	slog.Info("Serving API", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Error("Failed to serve API", "addr", addr, "error", err)
+	}
+}
+
+// serveGRPC 在 addr 上提供 gRPC API，ctx 结束后等待进行中的调用完成，超过 timeout 后强制关闭
+func serveGRPC(ctx context.Context, addr string, timeout time.Duration, resources *apiserver.ResourceService, system *apiserver.SystemService) {
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
-		log.Fatalf("Failed to create controller: %v", err)
+		slog.Error("Failed to serve gRPC API", "addr", addr, "error", err)
+		return
+	}
+	server := grpc.NewServer()
+	systemv1.RegisterHardwareConfigServiceServer(server, resources)
+	systemv1.RegisterSystemServiceServer(server, system)
+	// 支持 grpcurl 等工具查询服务定义
+	reflection.Register(server)
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			server.Stop()
+		}
+	}()
+
+	slog.Info("Serving gRPC API", "addr", addr)
+	if err := server.Serve(lis); err != nil {
+		slog.Error("Failed to serve gRPC API", "addr", addr, "error", err)
 	}
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// printSchema 输出资源类型的 JSON Schema，kind 为空时输出所有资源类型
+func printSchema(w io.Writer, kind, lang string) error {
+	var v any = schema.All(lang)
+	if kind != "" {
+		s, ok := schema.ForKind(kind, lang)
+		if !ok {
+			return fmt.Errorf("unknown resource kind: %s", kind)
+		}
+		v = s
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printPlans 以统一差异格式输出每个资源的变更预览
+func printPlans(w io.Writer, plans []controller.ResourcePlan) {
+	for i, plan := range plans {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if plan.Kind != "" {
+			fmt.Fprintf(w, "# %s/%s (%s)\n", plan.Kind, plan.Name, plan.Path)
+		} else {
+			fmt.Fprintf(w, "# %s\n", plan.Path)
+		}
+
+		switch {
+		case plan.Err != nil:
+			fmt.Fprintf(w, "Error: %v\n", plan.Err)
+			continue
+		case plan.Note != "":
+			fmt.Fprintf(w, "Skipped: %s\n", plan.Note)
+			continue
+		case len(plan.Changes) == 0 && len(plan.Commands) == 0:
+			fmt.Fprintln(w, "No changes.")
+			continue
+		}
 
-	log.Printf("Starting nix-operator with config directory: %s", *configDir)
-	if err := controller.Run(); err != nil {
-		log.Fatalf("Error running controller: %v", err)
+		for _, change := range plan.Changes {
+			fmt.Fprint(w, change.Diff)
+		}
+		for _, command := range plan.Commands {
+			fmt.Fprintf(w, "$ %s\n", strings.Join(command, " "))
+		}
 	}
 }
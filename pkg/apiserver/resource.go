@@ -0,0 +1,209 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// ResourceService 实现 HardwareConfigService，资源保存在控制器的配置目录中
+type ResourceService struct {
+	systemv1.UnimplementedHardwareConfigServiceServer
+
+	controller *controller.Controller
+}
+
+// NewResourceService 创建读写控制器配置目录的 HardwareConfigService
+func NewResourceService(c *controller.Controller) *ResourceService {
+	return &ResourceService{controller: c}
+}
+
+func (s *ResourceService) ListResourceConfigs(ctx context.Context, req *systemv1.ListResourceConfigsRequest) (*systemv1.ListResourceConfigsResponse, error) {
+	resources, err := s.controller.ListResources(req.GetKind())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &systemv1.ListResourceConfigsResponse{}
+	for _, resource := range resources {
+		resp.Resources = append(resp.Resources, toProtoResource(resource))
+	}
+	return resp, nil
+}
+
+func (s *ResourceService) GetResourceConfig(ctx context.Context, req *systemv1.GetResourceConfigRequest) (*systemv1.Resource, error) {
+	resource, err := s.controller.GetResource(req.GetKind(), req.GetName())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoResource(resource), nil
+}
+
+func (s *ResourceService) UpdateResourceConfig(ctx context.Context, req *systemv1.UpdateResourceConfigRequest) (*systemv1.Resource, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name: required value")
+	}
+	if req.GetResource().GetConfig() == nil {
+		return nil, status.Error(codes.InvalidArgument, "resource.config: required value")
+	}
+
+	cfg, err := fromProtoConfig(req.GetResource().GetConfig())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resource, err := s.controller.UpdateResource(req.GetName(), cfg)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return toProtoResource(resource), nil
+}
+
+// WatchResourceConfigs 持续发送资源的变化，订阅因处理过慢被关闭时返回 ABORTED，
+// 客户端可以用最后收到的序号重新监听
+func (s *ResourceService) WatchResourceConfigs(req *systemv1.WatchResourceConfigsRequest, stream grpc.ServerStreamingServer[systemv1.WatchEvent]) error {
+	ctx := stream.Context()
+	events, err := s.controller.Watch(ctx, req.GetSince())
+	if err != nil {
+		return grpcError(err)
+	}
+	for event := range events {
+		config := event.Resource.Config
+		if req.GetKind() != "" && config.Kind != req.GetKind() {
+			continue
+		}
+		if req.GetName() != "" && config.Metadata.Name != req.GetName() {
+			continue
+		}
+		if err := stream.Send(toProtoEvent(event)); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Aborted, "watch closed by server, watch again from the last received sequence")
+}
+
+// grpcError 将控制器返回的错误转换为 gRPC 状态
+func grpcError(err error) error {
+	var errs validation.ErrorList
+	switch {
+	case errors.As(err, &errs):
+		return status.Error(codes.InvalidArgument, errs.Error())
+	case errors.Is(err, controller.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, controller.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, controller.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, controller.ErrExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	slog.Error("API error", "error", err)
+	return status.Error(codes.Internal, err.Error())
+}
+
+var eventTypes = map[string]systemv1.EventType{
+	controller.EventAdded:    systemv1.EventType_ADDED,
+	controller.EventModified: systemv1.EventType_MODIFIED,
+	controller.EventDeleted:  systemv1.EventType_DELETED,
+}
+
+func toProtoEvent(event controller.Event) *systemv1.WatchEvent {
+	return &systemv1.WatchEvent{
+		Type:     eventTypes[event.Type],
+		Resource: toProtoResource(event.Resource),
+		Sequence: event.Sequence,
+	}
+}
+
+func toProtoResource(resource *controller.Resource) *systemv1.Resource {
+	return &systemv1.Resource{
+		Config:          toProtoConfig(resource.Config),
+		EffectiveConfig: toProtoConfig(resource.Effective),
+		Status:          toProtoStatus(resource.Status),
+	}
+}
+
+func toProtoConfig(cfg *config.ResourceConfig) *systemv1.ResourceConfig {
+	if cfg == nil {
+		return nil
+	}
+	return &systemv1.ResourceConfig{
+		ApiVersion: cfg.APIVersion,
+		Kind:       cfg.Kind,
+		Metadata: &systemv1.Metadata{
+			Name:            cfg.Metadata.Name,
+			ResourceVersion: cfg.Metadata.ResourceVersion,
+			Generation:      int32(cfg.Metadata.Generation),
+			CreationTime:    cfg.Metadata.CreationTime,
+			DeletionTime:    cfg.Metadata.DeletionTime,
+			Labels:          cfg.Metadata.Labels,
+			Annotations:     cfg.Metadata.Annotations,
+		},
+		Spec: compactJSON(cfg.Spec),
+	}
+}
+
+// compactJSON 去掉 spec 中的缩进和换行
+func compactJSON(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// fromProtoConfig 转换请求中的资源配置，spec 必须是 JSON 对象
+func fromProtoConfig(cfg *systemv1.ResourceConfig) (*config.ResourceConfig, error) {
+	result := &config.ResourceConfig{
+		APIVersion: cfg.GetApiVersion(),
+		Kind:       cfg.GetKind(),
+	}
+	if metadata := cfg.GetMetadata(); metadata != nil {
+		result.Metadata = config.Metadata{
+			Name:            metadata.GetName(),
+			ResourceVersion: metadata.GetResourceVersion(),
+			Generation:      int(metadata.GetGeneration()),
+			DeletionTime:    metadata.GetDeletionTime(),
+			Labels:          metadata.GetLabels(),
+			Annotations:     metadata.GetAnnotations(),
+		}
+	}
+	if cfg.GetSpec() != "" {
+		if !json.Valid([]byte(cfg.GetSpec())) {
+			return nil, validation.ErrorList{{Field: "spec", Message: "must be a JSON document"}}
+		}
+		result.Spec = json.RawMessage(cfg.GetSpec())
+	}
+	return result, nil
+}
+
+func toProtoStatus(s *config.ResourceStatus) *systemv1.ResourceStatus {
+	if s == nil {
+		return nil
+	}
+	result := &systemv1.ResourceStatus{
+		Phase:              s.Phase,
+		Reason:             s.Reason,
+		Message:            s.Message,
+		ObservedGeneration: int32(s.ObservedGeneration),
+	}
+	if t, err := time.Parse(time.RFC3339, s.LastReconcileTime); err == nil {
+		result.LastReconcileTime = timestamppb.New(t)
+	}
+	return result
+}
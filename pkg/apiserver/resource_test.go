@@ -0,0 +1,106 @@
+package apiserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+// newTestService 创建使用临时配置目录的 HardwareConfigService
+func newTestService(t *testing.T) (*ResourceService, string) {
+	t.Helper()
+	root := testutil.NewRoot(t)
+	testutil.WriteFile(t, root, "/etc/os-release", "ID=debian\nVERSION_ID=\"12\"\n")
+	configDir := t.TempDir()
+
+	c, err := controller.NewController(configDir, controller.WithRoot(root.Root()), controller.WithRunner(&testutil.FakeRunner{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewResourceService(c), configDir
+}
+
+func TestResourceService(t *testing.T) {
+	s, configDir := newTestService(t)
+	ctx := context.Background()
+	err := os.WriteFile(filepath.Join(configDir, "time.json"),
+		[]byte(`{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := s.ListResourceConfigs(ctx, &systemv1.ListResourceConfigsRequest{Kind: "TimeConfiguration"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].Config.Spec != `{"timezone":"UTC"}` {
+		t.Fatalf("got resources %v", list.Resources)
+	}
+
+	updated, err := s.UpdateResourceConfig(ctx, &systemv1.UpdateResourceConfigRequest{
+		Name: "time",
+		Resource: &systemv1.Resource{Config: &systemv1.ResourceConfig{
+			Kind: "TimeConfiguration",
+			Spec: `{"timezone": "Asia/Shanghai"}`,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Config.Metadata.Name != "time" {
+		t.Errorf("got updated resource %v", updated)
+	}
+
+	got, err := s.GetResourceConfig(ctx, &systemv1.GetResourceConfigRequest{Name: "time"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Config.Spec != `{"timezone":"Asia/Shanghai"}` {
+		t.Errorf("got spec %s", got.Config.Spec)
+	}
+}
+
+func TestResourceServiceErrors(t *testing.T) {
+	s, configDir := newTestService(t)
+	ctx := context.Background()
+
+	_, err := s.GetResourceConfig(ctx, &systemv1.GetResourceConfigRequest{Name: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("got %v, want NotFound", err)
+	}
+
+	_, err = s.UpdateResourceConfig(ctx, &systemv1.UpdateResourceConfigRequest{
+		Name:     "time",
+		Resource: &systemv1.Resource{Config: &systemv1.ResourceConfig{Kind: "TimeConfiguration", Spec: `{"timezone":`}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+
+	_, err = s.UpdateResourceConfig(ctx, &systemv1.UpdateResourceConfigRequest{Name: "time"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v, want InvalidArgument", err)
+	}
+
+	// 新资源的文件已被其他资源使用
+	err = os.WriteFile(filepath.Join(configDir, "time.json"),
+		[]byte(`{"kind": "TimeConfiguration", "metadata": {"name": "clock"}, "spec": {}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.UpdateResourceConfig(ctx, &systemv1.UpdateResourceConfigRequest{
+		Name:     "time",
+		Resource: &systemv1.Resource{Config: &systemv1.ResourceConfig{Kind: "TimeConfiguration", Spec: `{}`}},
+	})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("got %v, want AlreadyExists", err)
+	}
+}
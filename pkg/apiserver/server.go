@@ -0,0 +1,217 @@
+// Package apiserver 提供 nix-operator 的 gRPC 服务和 HTTP API
+//
+// HTTP API 按 proto 中的 google.api.http 注解把请求转发给 gRPC 服务的实现，
+// 消息使用 protojson 编码，错误与 gRPC 状态码一一对应
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/metrics"
+	"go.xbrother.com/nix-operator/pkg/schema"
+)
+
+// maxBodySize 是请求体的最大长度
+const maxBodySize = 1 << 20
+
+var (
+	marshalOptions   = protojson.MarshalOptions{EmitUnpopulated: true}
+	unmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// Server 是 HTTP API 的处理器
+type Server struct {
+	mux       *http.ServeMux
+	resources systemv1.HardwareConfigServiceServer
+	system    systemv1.SystemServiceServer
+}
+
+// NewServer 创建 API 处理器并注册所有路由，请求由 resources 和 system 处理
+func NewServer(resources systemv1.HardwareConfigServiceServer, system systemv1.SystemServiceServer) *Server {
+	s := &Server{
+		mux:       http.NewServeMux(),
+		resources: resources,
+		system:    system,
+	}
+	s.mux.HandleFunc("GET /v1/schemas", s.listSchemas)
+	s.mux.HandleFunc("GET /v1/schemas/{kind}", s.getSchema)
+	s.mux.HandleFunc("GET /v1/resources", s.listResources)
+	s.mux.HandleFunc("GET /v1/resources/{name}", s.getResource)
+	s.mux.HandleFunc("PUT /v1/resources/{name}", s.updateResource)
+	s.mux.HandleFunc("GET /v1/resources:watch", s.watchResources)
+	s.mux.HandleFunc("GET /v1/network_interfaces", s.listNetworkInterfaces)
+	s.mux.Handle("GET /metrics", metrics.Handler())
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// listSchemas 返回所有资源类型的 JSON Schema，键为资源类型
+func (s *Server) listSchemas(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, schema.All(lang(r)))
+}
+
+// getSchema 返回单个资源类型的 JSON Schema
+func (s *Server) getSchema(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	sch, ok := schema.ForKind(kind, lang(r))
+	if !ok {
+		writeError(w, status.Error(codes.NotFound, "unknown resource kind: "+kind))
+		return
+	}
+	writeJSON(w, http.StatusOK, sch)
+}
+
+func (s *Server) listResources(w http.ResponseWriter, r *http.Request) {
+	req := &systemv1.ListResourceConfigsRequest{Kind: r.URL.Query().Get("kind")}
+	resp, err := s.resources.ListResourceConfigs(r.Context(), req)
+	writeProto(w, resp, err)
+}
+
+func (s *Server) getResource(w http.ResponseWriter, r *http.Request) {
+	req := &systemv1.GetResourceConfigRequest{Name: r.PathValue("name"), Kind: r.URL.Query().Get("kind")}
+	resp, err := s.resources.GetResourceConfig(r.Context(), req)
+	writeProto(w, resp, err)
+}
+
+// updateResource 的请求体是 UpdateResourceConfigRequest，路径中的名称优先
+func (s *Server) updateResource(w http.ResponseWriter, r *http.Request) {
+	req := &systemv1.UpdateResourceConfigRequest{}
+	if err := readProto(r, req); err != nil {
+		writeError(w, err)
+		return
+	}
+	req.Name = r.PathValue("name")
+	resp, err := s.resources.UpdateResourceConfig(r.Context(), req)
+	writeProto(w, resp, err)
+}
+
+func (s *Server) listNetworkInterfaces(w http.ResponseWriter, r *http.Request) {
+	req := &systemv1.ListNetworkInterfacesRequest{}
+	if err := boolQuery(r, &req.LocalOnly, "local_only", "localOnly"); err != nil {
+		writeError(w, err)
+		return
+	}
+	resp, err := s.system.ListNetworkInterfaces(r.Context(), req)
+	writeProto(w, resp, err)
+}
+
+// lang 返回请求的描述语言，由查询参数 lang 指定，默认中文
+func lang(r *http.Request) string {
+	if r.URL.Query().Get("lang") == schema.LangEN {
+		return schema.LangEN
+	}
+	return schema.LangZH
+}
+
+// boolQuery 读取布尔查询参数，names 是参数的 proto 字段名和 JSON 名
+func boolQuery(r *http.Request, dst *bool, names ...string) error {
+	for _, name := range names {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%s: must be a boolean", name)
+		}
+		*dst = b
+	}
+	return nil
+}
+
+func readProto(r *http.Request, m proto.Message) error {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to read request body: %v", err)
+	}
+	if err := unmarshalOptions.Unmarshal(data, m); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// writeProto 以 protojson 输出服务的响应，err 不为空时输出错误
+func writeProto(w http.ResponseWriter, m proto.Message, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := marshalOptions.Marshal(m)
+	if err != nil {
+		writeError(w, status.Errorf(codes.Internal, "failed to marshal response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(data); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// writeError 输出 gRPC 状态对应的 HTTP 错误，响应体包含状态码和错误信息
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	data, err := marshalOptions.Marshal(st.Proto())
+	if err != nil {
+		http.Error(w, st.Message(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(st.Code()))
+	if _, err := w.Write(data); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+// httpStatus 返回 gRPC 状态码对应的 HTTP 状态码
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,167 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+
+	_ "go.xbrother.com/nix-operator/pkg/handlers/time"
+	"go.xbrother.com/nix-operator/pkg/schema"
+)
+
+func TestSchemas(t *testing.T) {
+	srv := httptest.NewServer(NewServer(nil, nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/schemas/TimeConfiguration?lang=en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	var s schema.Schema
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "TimeConfiguration" || s.Properties["timezone"].Description != "IANA time zone name" {
+		t.Errorf("got schema %+v", s)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var all map[string]*schema.Schema
+	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+		t.Fatal(err)
+	}
+	if all["TimeConfiguration"].Properties["timezone"].Description != "IANA 时区名称" {
+		t.Errorf("got schemas %+v", all)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/schemas/Unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got status %d for unknown kind", resp.StatusCode)
+	}
+}
+
+func TestResourceGateway(t *testing.T) {
+	resources, configDir := newTestService(t)
+	srv := httptest.NewServer(NewServer(resources, NewSystemService(nil)))
+	defer srv.Close()
+	err := os.WriteFile(filepath.Join(configDir, "time.json"),
+		[]byte(`{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list systemv1.ListResourceConfigsResponse
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources?kind=TimeConfiguration", "", http.StatusOK, &list)
+	if len(list.Resources) != 1 || list.Resources[0].Config.Metadata.Name != "time" {
+		t.Fatalf("got resources %v", list.Resources)
+	}
+
+	body := `{"resource": {"config": {"kind": "TimeConfiguration", "spec": "{\"timezone\": \"Asia/Shanghai\"}"}}}`
+	var updated systemv1.Resource
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/time", body, http.StatusOK, &updated)
+	if updated.Config.Metadata.Name != "time" {
+		t.Errorf("got updated resource %v", &updated)
+	}
+
+	var got systemv1.Resource
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources/time", "", http.StatusOK, &got)
+	if got.Config.Spec != `{"timezone":"Asia/Shanghai"}` {
+		t.Errorf("got spec %s", got.Config.Spec)
+	}
+
+	// 错误与 gRPC 状态码对应
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources/missing", "", http.StatusNotFound, nil)
+	stale := `{"resource": {"config": {"kind": "TimeConfiguration", "metadata": {"resourceVersion": "` +
+		list.Resources[0].Config.Metadata.ResourceVersion + `"}, "spec": "{}"}}}`
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/time", stale, http.StatusConflict, nil)
+	err = os.WriteFile(filepath.Join(configDir, "hosts.json"),
+		[]byte(`{"kind": "HostsConfiguration", "metadata": {"name": "other"}, "spec": {"hosts": []}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/hosts",
+		`{"resource": {"config": {"kind": "HostsConfiguration", "spec": "{\"hosts\": []}"}}}`, http.StatusConflict, nil)
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/time", `{"resource": `, http.StatusBadRequest, nil)
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/time",
+		`{"resource": {"config": {"kind": "HostsConfiguration", "spec": "{}"}}}`, http.StatusBadRequest, nil)
+}
+
+func TestNetworkInterfacesGateway(t *testing.T) {
+	srv := httptest.NewServer(NewServer(nil, NewSystemService(nil)))
+	defer srv.Close()
+
+	var resp systemv1.ListNetworkInterfacesResponse
+	doRequest(t, http.MethodGet, srv.URL+"/v1/network_interfaces?local_only=true", "", http.StatusOK, &resp)
+	for _, iface := range resp.Interfaces {
+		if iface.Name == "lo" {
+			t.Errorf("loopback interface listed: %v", iface)
+		}
+	}
+	doRequest(t, http.MethodGet, srv.URL+"/v1/network_interfaces?local_only=maybe", "", http.StatusBadRequest, nil)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	srv := httptest.NewServer(NewServer(nil, nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(data), "go_goroutines") {
+		t.Errorf("got status %d: %s", resp.StatusCode, data)
+	}
+}
+
+// doRequest 发送请求并检查状态码，resp 不为空时以 protojson 解析响应
+func doRequest(t *testing.T, method, url, body string, code int, resp proto.Message) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.StatusCode != code {
+		t.Fatalf("%s %s: got status %d, want %d: %s", method, url, r.StatusCode, code, data)
+	}
+	if resp != nil {
+		if err := protojson.Unmarshal(data, resp); err != nil {
+			t.Fatalf("%s %s: %v: %s", method, url, err, data)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/handlers/network"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// SystemService 实现 SystemService，返回本机的操作系统信息
+type SystemService struct {
+	systemv1.UnimplementedSystemServiceServer
+
+	controller *controller.Controller
+	links      func() ([]utils.Link, error)
+}
+
+// NewSystemService 创建 SystemService，网卡的期望配置来自控制器中的 NetworkConfiguration 资源
+func NewSystemService(c *controller.Controller) *SystemService {
+	return &SystemService{controller: c, links: utils.ListLinks}
+}
+
+// desiredInterface 是 NetworkConfiguration 资源中声明的一个接口
+type desiredInterface struct {
+	iface    network.Interface
+	resource string
+	local    bool // 节点选择器是否匹配本机
+}
+
+// ListNetworkInterfaces 返回本机网卡的实时状态和期望配置，不包括回环接口
+// local_only 为 false 时还返回已配置但本机不存在或不匹配本机的接口
+func (s *SystemService) ListNetworkInterfaces(ctx context.Context, req *systemv1.ListNetworkInterfacesRequest) (*systemv1.ListNetworkInterfacesResponse, error) {
+	links, err := s.links()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	desired, err := s.desiredInterfaces()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &systemv1.ListNetworkInterfacesResponse{}
+	found := make(map[string]bool)
+	for _, link := range links {
+		if link.Loopback {
+			continue
+		}
+		ni := toProtoLink(link)
+		for _, d := range desired {
+			if d.local && d.iface.Name == link.Name {
+				ni.Desired = toProtoInterface(d.iface)
+				ni.ResourceName = d.resource
+				found[link.Name] = true
+				break
+			}
+		}
+		resp.Interfaces = append(resp.Interfaces, ni)
+	}
+	if req.GetLocalOnly() {
+		return resp, nil
+	}
+
+	for _, d := range desired {
+		if d.local && found[d.iface.Name] {
+			continue
+		}
+		want := toProtoInterface(d.iface)
+		resp.Interfaces = append(resp.Interfaces, &systemv1.NetworkInterface{
+			NodeSelector: want.NodeSelector,
+			Name:         d.iface.Name,
+			Status:       systemv1.InterfaceStatus_Unknown,
+			Desired:      want,
+			ResourceName: d.resource,
+		})
+	}
+	return resp, nil
+}
+
+// desiredInterfaces 返回所有 NetworkConfiguration 资源中声明的接口，无法解析的资源被跳过
+func (s *SystemService) desiredInterfaces() ([]desiredInterface, error) {
+	if s.controller == nil {
+		return nil, nil
+	}
+	resources, err := s.controller.ListResources("NetworkConfiguration")
+	if err != nil {
+		return nil, err
+	}
+
+	var desired []desiredInterface
+	for _, resource := range resources {
+		var spec network.Config
+		if err := json.Unmarshal(resource.Config.Spec, &spec); err != nil {
+			slog.Error("Failed to decode spec", "kind", resource.Config.Kind, "name", resource.Config.Metadata.Name, "error", err)
+			continue
+		}
+		for _, iface := range spec.Interfaces {
+			local, err := utils.MatchNodeSelector(iface.NodeSelector)
+			if err != nil {
+				return nil, err
+			}
+			desired = append(desired, desiredInterface{iface: iface, resource: resource.Config.Metadata.Name, local: local})
+		}
+	}
+	return desired, nil
+}
+
+// toProtoLink 转换网卡的实时状态，IPv4 和 IPv6 各取第一个非链路本地地址
+func toProtoLink(link utils.Link) *systemv1.NetworkInterface {
+	ni := &systemv1.NetworkInterface{
+		Name:       link.Name,
+		Mtu:        int32(link.MTU),
+		MacAddress: link.MAC,
+		Status:     systemv1.InterfaceStatus_Down,
+		OperState:  link.OperState,
+		Carrier:    link.Carrier,
+	}
+	if link.Up {
+		ni.Status = systemv1.InterfaceStatus_Up
+	}
+
+	for _, addr := range link.Addresses {
+		ip := addr.Prefix.Addr()
+		switch {
+		case ip.Is4():
+			if ni.Ipv4 == nil {
+				ni.Ipv4 = &systemv1.IPv4Config{Address: addr.Prefix.String(), Gateway: link.Gateway4}
+			}
+			ni.Ipv4.DhcpEnabled = ni.Ipv4.DhcpEnabled || addr.Dynamic
+		case !ip.IsLinkLocalUnicast():
+			if ni.Ipv6 == nil {
+				ni.Ipv6 = &systemv1.IPv6Config{Address: addr.Prefix.String(), Gateway: link.Gateway6}
+			}
+			ni.Ipv6.SlaacEnabled = ni.Ipv6.SlaacEnabled || addr.Dynamic
+		}
+	}
+	return ni
+}
+
+// toProtoInterface 转换 NetworkConfiguration 中声明的接口
+func toProtoInterface(iface network.Interface) *systemv1.NetworkInterface {
+	ni := &systemv1.NetworkInterface{
+		Name:       iface.Name,
+		Mtu:        int32(iface.MTU),
+		MacAddress: iface.MACAddress,
+	}
+	if iface.NodeSelector != (utils.NodeSelector{}) {
+		ni.NodeSelector = &systemv1.NodeSelector{
+			Hostname:   iface.NodeSelector.Hostname,
+			MacAddress: iface.NodeSelector.MACAddress,
+		}
+	}
+	if iface.IPv4Mode() != network.AddressDisabled {
+		ni.Ipv4 = &systemv1.IPv4Config{
+			Address:     iface.IPAddress,
+			Gateway:     iface.Gateway,
+			DhcpEnabled: iface.IPv4Mode() == network.AddressDHCP,
+		}
+	}
+	if iface.IPv6Mode() != network.AddressDisabled {
+		ni.Ipv6 = &systemv1.IPv6Config{
+			Address:      iface.IPv6Address,
+			Gateway:      iface.IPv6Gateway,
+			SlaacEnabled: iface.AcceptRA(),
+		}
+	}
+	return ni
+}
@@ -0,0 +1,88 @@
+package apiserver
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+func TestListNetworkInterfaces(t *testing.T) {
+	resources, configDir := newTestService(t)
+	err := os.WriteFile(filepath.Join(configDir, "network.json"), []byte(`{
+		"kind": "NetworkConfiguration",
+		"metadata": {"name": "network"},
+		"spec": {"interfaces": [
+			{"name": "eth0", "ipAddress": "192.168.1.10/24", "gateway": "192.168.1.1"},
+			{"name": "eth1", "ipAddress": "10.0.0.2/8", "ipv6Method": "slaac"},
+			{"name": "eth0", "nodeSelector": {"hostname": "other-host"}, "ipAddress": "192.168.1.20/24"}
+		]}
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSystemService(resources.controller)
+	s.links = func() ([]utils.Link, error) {
+		return []utils.Link{
+			{Name: "lo", Up: true, Loopback: true},
+			{
+				Name: "eth0", MTU: 1500, MAC: "00:11:22:33:44:55", Up: true, OperState: "up", Carrier: true,
+				Addresses: []utils.LinkAddress{
+					{Prefix: netip.MustParsePrefix("192.168.1.23/24"), Dynamic: true},
+					{Prefix: netip.MustParsePrefix("fe80::1/64")},
+					{Prefix: netip.MustParsePrefix("2001:db8::1/64")},
+				},
+				Gateway4: "192.168.1.1",
+			},
+			{Name: "eth2", Up: false, OperState: "down"},
+		}, nil
+	}
+
+	resp, err := s.ListNetworkInterfaces(context.Background(), &systemv1.ListNetworkInterfacesRequest{LocalOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Interfaces) != 2 {
+		t.Fatalf("got %d interfaces, want eth0 and eth2: %v", len(resp.Interfaces), resp.Interfaces)
+	}
+	eth0 := resp.Interfaces[0]
+	if eth0.Status != systemv1.InterfaceStatus_Up || !eth0.Carrier || eth0.OperState != "up" {
+		t.Errorf("got eth0 state %v", eth0)
+	}
+	if eth0.Ipv4.Address != "192.168.1.23/24" || eth0.Ipv4.Gateway != "192.168.1.1" || !eth0.Ipv4.DhcpEnabled {
+		t.Errorf("got eth0 ipv4 %v", eth0.Ipv4)
+	}
+	if eth0.Ipv6.Address != "2001:db8::1/64" || eth0.Ipv6.SlaacEnabled {
+		t.Errorf("got eth0 ipv6 %v", eth0.Ipv6)
+	}
+	if eth0.ResourceName != "network" || eth0.Desired.Ipv4.Address != "192.168.1.10/24" {
+		t.Errorf("got eth0 desired %v from %s", eth0.Desired, eth0.ResourceName)
+	}
+	if eth2 := resp.Interfaces[1]; eth2.Status != systemv1.InterfaceStatus_Down || eth2.Desired != nil {
+		t.Errorf("got eth2 %v", eth2)
+	}
+
+	// 已配置但本机不存在以及不匹配本机的接口
+	resp, err = s.ListNetworkInterfaces(context.Background(), &systemv1.ListNetworkInterfacesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Interfaces) != 4 {
+		t.Fatalf("got %d interfaces, want 4: %v", len(resp.Interfaces), resp.Interfaces)
+	}
+	eth1, other := resp.Interfaces[2], resp.Interfaces[3]
+	if eth1.Name != "eth1" || eth1.Status != systemv1.InterfaceStatus_Unknown || eth1.Desired.Ipv4.Address != "10.0.0.2/8" {
+		t.Errorf("got eth1 %v", eth1)
+	}
+	if eth1.Desired.Ipv4.DhcpEnabled || !eth1.Desired.Ipv6.GetSlaacEnabled() {
+		t.Errorf("got eth1 desired addressing %v", eth1.Desired)
+	}
+	if other.Name != "eth0" || other.NodeSelector.GetHostname() != "other-host" {
+		t.Errorf("got eth0 of other host %v", other)
+	}
+}
@@ -0,0 +1,175 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+)
+
+const (
+	// defaultPollTimeout 是长轮询没有事件时的默认等待时间
+	defaultPollTimeout = 30 * time.Second
+	maxPollTimeout     = 5 * time.Minute
+	// pollSettle 是收到第一个事件后继续收集事件的时间，使一次调谐的多个变化一起返回
+	pollSettle = 100 * time.Millisecond
+)
+
+// watchStream 将 WatchResourceConfigs 的服务端流适配为 HTTP 响应
+type watchStream struct {
+	ctx  context.Context
+	send func(*systemv1.WatchEvent) error
+}
+
+func (s *watchStream) Send(event *systemv1.WatchEvent) error { return s.send(event) }
+func (s *watchStream) Context() context.Context              { return s.ctx }
+func (s *watchStream) SetHeader(metadata.MD) error           { return nil }
+func (s *watchStream) SendHeader(metadata.MD) error          { return nil }
+func (s *watchStream) SetTrailer(metadata.MD)                {}
+func (s *watchStream) SendMsg(m any) error                   { return s.send(m.(*systemv1.WatchEvent)) }
+func (s *watchStream) RecvMsg(m any) error                   { return nil }
+
+// watchResources 以 SSE 或长轮询的方式监听资源的变化
+// 请求头 Accept 包含 text/event-stream 时使用 SSE，事件 id 是事件序号，
+// 浏览器重连时通过 Last-Event-ID 从断开处继续；否则使用长轮询，
+// 客户端用返回的最后一个事件的序号作为下一次请求的 since
+func (s *Server) watchResources(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &systemv1.WatchResourceConfigsRequest{Kind: query.Get("kind"), Name: query.Get("name")}
+	since := query.Get("since")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	if since != "" {
+		n, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			writeError(w, status.Error(codes.InvalidArgument, "since: must be a non-negative integer"))
+			return
+		}
+		req.Since = n
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.streamEvents(w, r, req)
+		return
+	}
+	s.pollEvents(w, r, req)
+}
+
+// streamEvents 以 SSE 持续发送事件，监听失败时发送 error 事件
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, req *systemv1.WatchResourceConfigsRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, status.Error(codes.Internal, "streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	stream := &watchStream{ctx: r.Context(), send: func(event *systemv1.WatchEvent) error {
+		data, err := marshalOptions.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}}
+	err := s.resources.WatchResourceConfigs(req, stream)
+	if err == nil || r.Context().Err() != nil {
+		return
+	}
+	data, err := marshalOptions.Marshal(status.Convert(err).Proto())
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+	flusher.Flush()
+}
+
+// pollEvents 等待事件并一次性返回，等待时间由查询参数 timeout 指定
+func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request, req *systemv1.WatchResourceConfigsRequest) {
+	timeout := defaultPollTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			writeError(w, status.Error(codes.InvalidArgument, "timeout: must be a positive duration, e.g. 30s"))
+			return
+		}
+		timeout = min(d, maxPollTimeout)
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	var (
+		mu     sync.Mutex
+		events []json.RawMessage
+		first  = make(chan struct{})
+	)
+	stream := &watchStream{ctx: ctx, send: func(event *systemv1.WatchEvent) error {
+		data, err := marshalOptions.Marshal(event)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, data)
+		if len(events) == 1 {
+			close(first)
+		}
+		return nil
+	}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.resources.WatchResourceConfigs(req, stream)
+	}()
+
+	var (
+		err      error
+		finished bool
+	)
+	select {
+	case err = <-done:
+		finished = true
+		// 超时结束的监听不是错误
+		if ctx.Err() != nil {
+			err = nil
+		}
+	case <-first:
+		select {
+		case <-time.After(pollSettle):
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+	}
+	cancel()
+	if !finished {
+		<-done
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err != nil && len(events) == 0 {
+		writeError(w, err)
+		return
+	}
+	if events == nil {
+		events = []json.RawMessage{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]json.RawMessage{"events": events})
+}
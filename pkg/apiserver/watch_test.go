@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	systemv1 "go.xbrother.com/nix-operator/api/system/v1"
+)
+
+func TestWatchGateway(t *testing.T) {
+	resources, configDir := newTestService(t)
+	srv := httptest.NewServer(NewServer(resources, nil))
+	defer srv.Close()
+	err := os.WriteFile(filepath.Join(configDir, "time.json"),
+		[]byte(`{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 长轮询先返回已有资源
+	events := pollWatch(t, srv.URL+"/v1/resources:watch?kind=TimeConfiguration&timeout=1s")
+	if len(events) != 1 || events[0].Type != systemv1.EventType_ADDED || events[0].Resource.Config.Metadata.Name != "time" {
+		t.Fatalf("got events %v", events)
+	}
+	// 过滤掉的类型和没有新事件时超时返回空列表
+	if events := pollWatch(t, srv.URL+"/v1/resources:watch?kind=HostsConfiguration&timeout=100ms"); len(events) != 0 {
+		t.Errorf("got events %v for filtered kind", events)
+	}
+
+	body := `{"resource": {"config": {"kind": "TimeConfiguration", "spec": "{\"timezone\": \"Asia/Shanghai\"}"}}}`
+	doRequest(t, http.MethodPut, srv.URL+"/v1/resources/time", body, http.StatusOK, nil)
+
+	// SSE 先发送已有资源，Last-Event-ID 为 0 时与不带该请求头相同
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/resources:watch?name=time", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %s", ct)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 3 || lines[0] != "id: 0" || lines[1] != "event: ADDED" {
+		t.Fatalf("got SSE event %q", lines)
+	}
+	var event systemv1.WatchEvent
+	if err := protojson.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Resource.Config.Spec != `{"timezone":"Asia/Shanghai"}` {
+		t.Errorf("got spec %s", event.Resource.Config.Spec)
+	}
+
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources:watch?since=abc", "", http.StatusBadRequest, nil)
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources:watch?since=1000", "", http.StatusBadRequest, nil)
+	doRequest(t, http.MethodGet, srv.URL+"/v1/resources:watch?timeout=0", "", http.StatusBadRequest, nil)
+}
+
+// pollWatch 发送长轮询请求并返回事件
+func pollWatch(t *testing.T, url string) []*systemv1.WatchEvent {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	var body struct {
+		Events []json.RawMessage `json:"events"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	var events []*systemv1.WatchEvent
+	for _, data := range body.Events {
+		var event systemv1.WatchEvent
+		if err := protojson.Unmarshal(data, &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, &event)
+	}
+	return events
+}
@@ -2,12 +2,30 @@ package config
 
 import (
 	"encoding/json"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
 )
 
 const (
 	CommentHeader = "# Generated by nix-operator. DO NOT EDIT.\n"
 )
 
+// 资源状态阶段
+const (
+	PhasePending = "Pending" // 等待条件满足后重新调谐
+	PhaseReady   = "Ready"   // 配置已成功应用
+	PhaseFailed  = "Failed"  // 配置应用失败
+	PhaseDeleted = "Deleted" // 资源已标记删除，生成的文件已清理
+)
+
+// 配置漂移处理策略，通过资源注解指定
+const (
+	AnnotationDriftPolicy = "nix-operator/drift-policy"
+
+	DriftPolicyReport  = "report"  // 仅在状态中报告漂移（默认）
+	DriftPolicyCorrect = "correct" // 自动恢复为期望配置
+)
+
 type ResourceConfig struct {
 	APIVersion string          `json:"apiVersion"`
 	Kind       string          `json:"kind"`
@@ -26,7 +44,30 @@ type Metadata struct {
 }
 
 type ResourceStatus struct {
-	Phase   string `json:"phase"`
-	Reason  string `json:"reason"`
-	Message string `json:"message"`
+	Phase              string `json:"phase"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	LastReconcileTime  string `json:"lastReconcileTime"`
+	ObservedGeneration int    `json:"observedGeneration"`
+	// Drift 是周期性检查发现的被手工修改的文件
+	Drift []FileDrift `json:"drift,omitempty"`
+	// FieldErrors 是 spec 校验失败的字段
+	FieldErrors []validation.FieldError `json:"fieldErrors,omitempty"`
+	// Commands 是最近一次调谐或清理执行的命令
+	Commands []CommandRecord `json:"commands,omitempty"`
+}
+
+// FileDrift 描述系统中的文件与期望配置的差异
+type FileDrift struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"` // 从期望内容到当前内容的统一差异格式文本
+}
+
+// CommandRecord 记录处理器执行的一条命令
+type CommandRecord struct {
+	Command  []string `json:"command"`          // 第一个元素是可执行文件
+	ExitCode int      `json:"exitCode"`         // 命令无法启动时为 -1
+	Output   string   `json:"output,omitempty"` // 标准输出和标准错误
+	Error    string   `json:"error,omitempty"`
+	Duration string   `json:"duration"`
 }
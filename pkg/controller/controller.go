@@ -2,14 +2,21 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/metrics"
+	"go.xbrother.com/nix-operator/pkg/utils"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -21,15 +28,157 @@ type OSInfo struct {
 	KernelVer  string // 内核版本
 }
 
+const (
+	// DefaultDebounce 是合并文件事件的默认等待时间
+	DefaultDebounce = 200 * time.Millisecond
+
+	// 调谐失败后的默认重试参数
+	DefaultRetryBase  = time.Second
+	DefaultRetryMax   = 5 * time.Minute
+	DefaultMaxRetries = 10
+
+	// DefaultResyncPeriod 是周期性漂移检查的默认间隔
+	DefaultResyncPeriod = 10 * time.Minute
+
+	// DefaultReconcileTimeout 是单个资源调谐或清理的默认超时时间
+	DefaultReconcileTimeout = 5 * time.Minute
+	// DefaultDrainTimeout 是退出时等待进行中的调谐完成的默认时间
+	DefaultDrainTimeout = 30 * time.Second
+)
+
 type Controller struct {
-	configDir string
-	handlers  map[string]Handler // key 是处理器类型
-	osInfo    OSInfo
+	configDir    string
+	statusDir    string
+	readOnly     bool
+	debounce     time.Duration
+	retryBase    time.Duration
+	retryMax     time.Duration
+	maxRetries   int
+	resyncPeriod time.Duration
+	timeout      time.Duration
+	drainTimeout time.Duration
+	fs           utils.FS
+	runner       utils.Runner
+	handlers     map[string]Handler // key 是处理器类型
+	osInfo       OSInfo
+	status       *StatusStore
+	queue        *WorkQueue // key 是配置文件路径
+	events       *broadcaster
+	notifier     *utils.Notifier
+	watchdog     time.Duration
+
+	// 以下字段用于向 systemd 报告状态，除 busySince 和 lastLoop 外只在工作循环中访问
+	busySince  atomic.Int64 // 正在调谐的资源的开始时间（UnixNano），空闲时为 0
+	lastLoop   atomic.Int64 // 工作循环最近一次取出队列或空闲等待结束的时间（UnixNano）
+	ready      bool
+	lastStatus string
+
+	// mu 保护资源索引及其中的摘要，调谐和清理期间不持有
+	mu        sync.Mutex
+	resources map[string]*resourceEntry // key 是配置文件路径
+	owners    map[string]string         // key 是资源的类型和名称，值是最先声明该资源的配置文件路径
+	exclusive map[string]string         // key 是只允许一个资源的类型，值是最先声明的资源的类型和名称
+
+	storeMu sync.Mutex // 串行化通过 API 对配置文件的修改
+}
+
+// resourceEntry 记录配置文件最近一次加载的资源
+type resourceEntry struct {
+	configs []*config.ResourceConfig
+	hash    string // 最近一次文件中所有资源都调谐成功时的内容摘要，失败时为空以便重试
+	// duplicates 是已由其他配置文件声明的资源，以及只允许一个资源的类型中后声明的资源
+	// 它们不被调谐，先声明的资源被删除后重新处理本文件
+	duplicates []*config.ResourceConfig
+}
+
+// Option 用于定制控制器
+type Option func(*Controller)
+
+// WithStatusDir 指定资源状态的保存目录，默认为配置目录下的 .status
+func WithStatusDir(dir string) Option {
+	return func(c *Controller) {
+		c.statusDir = dir
+	}
+}
+
+// WithReadOnlyStatus 以只读方式使用状态目录，用于预览变更时不修改状态目录
+func WithReadOnlyStatus() Option {
+	return func(c *Controller) {
+		c.readOnly = true
+	}
+}
+
+// WithDebounce 指定合并文件事件的等待时间，编辑器保存文件时通常会产生一连串事件
+func WithDebounce(d time.Duration) Option {
+	return func(c *Controller) {
+		c.debounce = d
+	}
+}
+
+// WithRetry 指定调谐失败后的指数退避参数和最大重试次数
+func WithRetry(base, max time.Duration, maxRetries int) Option {
+	return func(c *Controller) {
+		c.retryBase = base
+		c.retryMax = max
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithResyncPeriod 指定周期性漂移检查的间隔，为 0 时禁用
+func WithResyncPeriod(d time.Duration) Option {
+	return func(c *Controller) {
+		c.resyncPeriod = d
+	}
+}
+
+// WithReconcileTimeout 指定单个资源调谐或清理的超时时间，超时后处理器执行的命令被终止
+func WithReconcileTimeout(d time.Duration) Option {
+	return func(c *Controller) {
+		c.timeout = d
+	}
+}
+
+// WithDrainTimeout 指定退出时等待进行中的调谐完成的时间，超时后取消调谐
+func WithDrainTimeout(d time.Duration) Option {
+	return func(c *Controller) {
+		c.drainTimeout = d
+	}
+}
+
+// WithNotifier 指定向 systemd 报告就绪和资源状态的 Notifier
+func WithNotifier(n *utils.Notifier) Option {
+	return func(c *Controller) {
+		c.notifier = n
+	}
+}
+
+// WithWatchdog 指定 systemd 的看门狗超时时间，为 0 时不发送看门狗通知
+func WithWatchdog(d time.Duration) Option {
+	return func(c *Controller) {
+		c.watchdog = d
+	}
+}
+
+// WithRoot 指定被管理系统的根目录，所有生成的文件都写入该目录下，默认为 "/"
+// 只有文件的读写被重定向，处理器执行的命令仍作用于当前运行的系统
+func WithRoot(root string) Option {
+	return func(c *Controller) {
+		c.fs = utils.NewRootFS(root)
+	}
+}
+
+// WithRunner 指定处理器执行外部命令使用的执行器
+func WithRunner(runner utils.Runner) Option {
+	return func(c *Controller) {
+		c.runner = runner
+	}
 }
 
 type ReconcileResult struct {
 	Effective *config.ResourceConfig
 	Status    *config.ResourceStatus
+	// RequeueAfter 大于 0 时，控制器会在该时间后再次调谐资源
+	RequeueAfter time.Duration
 }
 
 type Handler interface {
@@ -37,6 +186,20 @@ type Handler interface {
 	Match(osInfo OSInfo) bool
 	// Reconcile 处理配置
 	Reconcile(ctx context.Context, config *config.ResourceConfig) (*ReconcileResult, error)
+	// Cleanup 在资源被删除时清理生成的文件，恢复原有配置并重新加载相应服务
+	Cleanup(ctx context.Context, config *config.ResourceConfig) error
+}
+
+// Exclusive 由管理固定文件（如 /etc/hosts）的处理器实现，这类资源每种类型只能有一个
+// 后声明的资源不被调谐，删除它时也不会清理仍由先声明的资源使用的文件
+type Exclusive interface {
+	// Exclusive 返回 true 表示该类型只允许一个资源
+	Exclusive() bool
+}
+
+// Stopper 由运行后台服务（如串口透传）的处理器实现，控制器退出时调用 Stop 释放资源
+type Stopper interface {
+	Stop(ctx context.Context) error
 }
 
 var handlerFactories = make(map[string][]Handler)
@@ -46,26 +209,47 @@ func RegisterHandler(typeName string, handler Handler) {
 	handlerFactories[typeName] = append(handlerFactories[typeName], handler)
 }
 
-func NewController(configDir string) (*Controller, error) {
-	osInfo, err := getOSInfo()
+func NewController(configDir string, opts ...Option) (*Controller, error) {
+	c := &Controller{
+		configDir:    configDir,
+		statusDir:    filepath.Join(configDir, StatusDirName),
+		debounce:     DefaultDebounce,
+		retryBase:    DefaultRetryBase,
+		retryMax:     DefaultRetryMax,
+		maxRetries:   DefaultMaxRetries,
+		resyncPeriod: DefaultResyncPeriod,
+		timeout:      DefaultReconcileTimeout,
+		drainTimeout: DefaultDrainTimeout,
+		fs:           utils.HostFS,
+		runner:       utils.HostRunner,
+		handlers:     make(map[string]Handler),
+		resources:    make(map[string]*resourceEntry),
+		owners:       make(map[string]string),
+		exclusive:    make(map[string]string),
+		events:       newBroadcaster(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	osInfo, err := getOSInfo(c.fs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get OS info: %v", err)
 	}
-
-	handlers := make(map[string]Handler)
+	c.osInfo = osInfo
 
 	// 为每种类型选择合适的处理器
 	requiredTypes := []string{
-		"network",
-		"hosts",
-		"time",
-		"serial",
-		"udev",
+		"NetworkConfiguration",
+		"HostsConfiguration",
+		"TimeConfiguration",
+		"SerialConfiguration",
+		"UdevConfiguration",
 	}
 	for _, typeName := range requiredTypes {
 		typedHandlers := handlerFactories[typeName]
 		if len(typedHandlers) == 0 {
-			log.Printf("Warning: no handler registered for type: %s", typeName)
+			slog.Warn("No handler registered", "kind", typeName)
 			continue
 		}
 
@@ -73,27 +257,89 @@ func NewController(configDir string) (*Controller, error) {
 		var matched bool
 		for _, handler := range typedHandlers {
 			if handler.Match(osInfo) {
-				handlers[typeName] = handler
+				c.handlers[typeName] = handler
 				matched = true
 				break
 			}
 		}
 
 		if !matched {
-			log.Printf("Warning: no compatible handler found for type %s on OS %s %s",
-				typeName, osInfo.ID, osInfo.VersionID)
+			slog.Warn("No compatible handler found", "kind", typeName, "os", osInfo.ID, "version", osInfo.VersionID)
+		}
+	}
+
+	c.queue = NewWorkQueue(NewExponentialBackoff(c.retryBase, c.retryMax))
+
+	if c.readOnly {
+		c.status = NewReadOnlyStatusStore(c.statusDir)
+	} else {
+		c.status = NewStatusStore(c.statusDir)
+	}
+
+	// 从上次运行保存的状态恢复资源索引，以便清理停机期间被删除的资源
+	states, err := c.status.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource status: %v", err)
+	}
+	for _, state := range states {
+		if state.Path == "" {
+			continue
+		}
+		cfg := state.Effective
+		if cfg == nil {
+			cfg = &config.ResourceConfig{Kind: state.Kind, Metadata: config.Metadata{Name: state.Name}}
+		}
+		entry, ok := c.resources[state.Path]
+		if !ok {
+			entry = &resourceEntry{}
+			c.resources[state.Path] = entry
+		}
+		entry.configs = append(entry.configs, cfg)
+		c.owners[resourceKey(cfg)] = state.Path
+		if c.isExclusive(cfg.Kind) {
+			c.exclusive[cfg.Kind] = resourceKey(cfg)
+		}
+	}
+
+	// 启动前已存在的资源的变化以 MODIFIED 事件发出
+	resources, err := c.ListResources("")
+	if err != nil {
+		slog.Error("Failed to list resources", "error", err)
+	}
+	var owned []*Resource
+	for _, resource := range resources {
+		if c.duplicateOwner(resource.Path, resource.Config) == "" {
+			owned = append(owned, resource)
+		}
+	}
+	c.events.seed(owned)
+	for _, resource := range owned {
+		if resource.Status != nil {
+			metrics.SetPhase(resource.Config.Kind, resource.Config.Metadata.Name, resource.Status.Phase)
 		}
 	}
 
-	return &Controller{
-		configDir: configDir,
-		handlers:  handlers,
-		osInfo:    osInfo,
-	}, nil
+	return c, nil
+}
+
+// handlerContext 返回调用处理器时使用的 context，处理器通过它访问根文件系统和执行命令
+func (c *Controller) handlerContext() context.Context {
+	return c.withHandlerValues(context.Background())
+}
+
+func (c *Controller) withHandlerValues(ctx context.Context) context.Context {
+	ctx = utils.WithFS(ctx, c.fs)
+	return utils.WithRunner(ctx, c.runner)
 }
 
-func getOSInfo() (OSInfo, error) {
-	data, err := os.ReadFile("/etc/os-release")
+// StatusStore 返回控制器使用的状态存储
+func (c *Controller) StatusStore() *StatusStore {
+	return c.status
+}
+
+// getOSInfo 从根文件系统读取发行版信息，内核信息始终来自当前运行的内核
+func getOSInfo(fsys utils.FS) (OSInfo, error) {
+	data, err := fsys.ReadFile("/etc/os-release")
 	if err != nil {
 		return OSInfo{}, err
 	}
@@ -125,25 +371,21 @@ func getOSInfo() (OSInfo, error) {
 	return info, nil
 }
 
-func (c *Controller) Run() error {
+// Run 调谐所有资源并监听配置目录，直到 ctx 结束
+// 退出时不再处理新的变化，等待进行中的调谐完成，超过 drainTimeout 后取消调谐，
+// 最后停止处理器的后台服务
+func (c *Controller) Run(ctx context.Context) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
 	defer watcher.Close()
 
-	go func() {
-		for {
-			select {
-			case event := <-watcher.Events:
-				if event.Op&fsnotify.Write == fsnotify.Write {
-					c.reconcile()
-				}
-			case err := <-watcher.Errors:
-				log.Printf("Error: %v", err)
-			}
-		}
-	}()
+	// 调谐使用独立的 context，收到退出信号时不会中断正在写入的配置或正在执行的命令
+	workCtx, cancelWork := context.WithCancel(c.withHandlerValues(context.WithoutCancel(ctx)))
+	defer cancelWork()
+
+	go c.watch(ctx, watcher)
 
 	// 监控配置目录
 	err = watcher.Add(c.configDir)
@@ -157,6 +399,9 @@ func (c *Controller) Run() error {
 			return err
 		}
 		if info.IsDir() {
+			if isHidden(path, c.configDir) {
+				return filepath.SkipDir
+			}
 			return watcher.Add(path)
 		}
 		return nil
@@ -166,73 +411,685 @@ func (c *Controller) Run() error {
 	}
 
 	// 初始调谐
-	c.reconcile()
+	c.enqueueAll()
+
+	// 周期性检查配置漂移
+	if c.resyncPeriod > 0 {
+		go func() {
+			ticker := time.NewTicker(c.resyncPeriod)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					c.resync(workCtx)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// 退出前持续发送看门狗通知，包括等待调谐完成期间
+	c.lastLoop.Store(time.Now().UnixNano())
+	if c.notifier != nil && c.watchdog > 0 {
+		stopWatchdog := make(chan struct{})
+		defer close(stopWatchdog)
+		go c.runWatchdog(ctx.Done(), stopWatchdog)
+	}
+
+	// 处理工作队列，队列第一次处理完时报告就绪，退出时丢弃尚未开始的调谐
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			c.notifyStatus(c.queue.Len() == 0)
+			if !c.processNextItem(workCtx) {
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutting down, waiting for in-flight reconciles", "timeout", c.drainTimeout)
+	if err := c.notifier.Notify("STOPPING=1", "STATUS=Shutting down"); err != nil {
+		slog.Warn("Failed to notify systemd", "error", err)
+	}
+	c.queue.ShutDown()
+	select {
+	case <-done:
+	case <-time.After(c.drainTimeout):
+		slog.Warn("Reconciles did not finish in time, cancelling", "timeout", c.drainTimeout)
+		cancelWork()
+		<-done
+	}
+
+	c.stopHandlers(workCtx)
+	// 结束所有监听，使 API 服务可以退出
+	c.events.close()
+	slog.Info("Controller stopped")
+	return nil
+}
+
+// watch 合并短时间内的连续文件事件，只将发生变化的路径加入队列
+func (c *Controller) watch(ctx context.Context, watcher *fsnotify.Watcher) {
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(c.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			countFileEvent(event)
+			// 监控新建的子目录
+			if event.Has(fsnotify.Create) && !isHidden(event.Name, c.configDir) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						slog.Error("Failed to watch directory", "path", event.Name, "error", err)
+					}
+				}
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) ||
+				event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				pending[event.Name] = struct{}{}
+				timer.Reset(c.debounce)
+			}
+		case <-timer.C:
+			for path := range pending {
+				c.enqueuePath(path)
+			}
+			pending = make(map[string]struct{})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("File watcher error", "error", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// countFileEvent 按操作类型统计文件事件，一个事件可能包含多个操作
+func countFileEvent(event fsnotify.Event) {
+	for _, op := range []fsnotify.Op{fsnotify.Create, fsnotify.Write, fsnotify.Remove, fsnotify.Rename, fsnotify.Chmod} {
+		if event.Has(op) {
+			metrics.FileEvents.WithLabelValues(strings.ToLower(op.String())).Inc()
+		}
+	}
+}
 
-	// 保持运行
-	select {}
+// stopHandlers 停止所有处理器的后台服务
+func (c *Controller) stopHandlers(ctx context.Context) {
+	for kind, handler := range c.handlers {
+		stopper, ok := handler.(Stopper)
+		if !ok {
+			continue
+		}
+		if err := stopper.Stop(ctx); err != nil {
+			slog.Error("Failed to stop handler", "kind", kind, "error", err)
+		}
+	}
 }
 
-func (c *Controller) reconcile() {
-	ctx := context.Background()
+// enqueueAll 将配置目录中的所有配置文件以及已被删除的资源加入队列
+func (c *Controller) enqueueAll() {
+	files, err := c.configFiles()
+	if err != nil {
+		slog.Error("Failed to walk config directory", "path", c.configDir, "error", err)
+		return
+	}
+	seen := make(map[string]bool)
+	for _, path := range files {
+		seen[path] = true
+		c.queue.Add(path)
+	}
 
-	// 扫描配置目录中的所有配置文件
-	err := filepath.Walk(c.configDir, func(path string, info os.FileInfo, err error) error {
+	// 配置文件已被删除的资源
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for path := range c.resources {
+		if !seen[path] {
+			c.queue.Add(path)
+		}
+	}
+}
+
+// enqueuePath 将文件事件涉及的配置文件加入队列
+func (c *Controller) enqueuePath(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("Failed to read path", "path", path, "error", err)
+			return
+		}
+		// 文件或目录已被删除，清理其下的所有资源
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for indexed := range c.resources {
+			if indexed == path || strings.HasPrefix(indexed, path+string(filepath.Separator)) {
+				c.queue.Add(indexed)
+			}
+		}
+		return
+	}
+
+	if !info.IsDir() {
+		if c.isConfigFile(path) {
+			c.queue.Add(path)
+		}
+		return
+	}
+
+	// 新建或移入的目录
+	if isHidden(path, c.configDir) {
+		return
+	}
+	err = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-
-		// 跳过目录和非YAML/JSON文件
 		if info.IsDir() {
+			if isHidden(path, c.configDir) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
+		if c.isConfigFile(path) {
+			c.queue.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to walk directory", "path", path, "error", err)
+	}
+}
 
-		ext := filepath.Ext(path)
-		if ext != ".json" {
-			return nil
+// processNextItem 处理队列中的一个配置文件，失败时按指数退避重试
+// 启用看门狗时空闲等待定期结束，以便看门狗确认工作循环仍在推进
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	path, shutdown := c.queue.GetTimeout(c.idleTimeout())
+	c.lastLoop.Store(time.Now().UnixNano())
+	if shutdown {
+		return false
+	}
+	if path == "" {
+		return true // 空闲等待超时
+	}
+	defer c.queue.Done(path)
+
+	requeueAfter, err := c.syncPath(ctx, path)
+	switch {
+	case err != nil:
+		if c.queue.NumRequeues(path) < c.maxRetries {
+			delay := c.queue.AddRateLimited(path)
+			slog.Warn("Retrying config file", "path", path, "delay", delay, "error", err)
+		} else {
+			c.queue.Forget(path)
+			slog.Error("Giving up on config file", "path", path, "retries", c.maxRetries, "error", err)
 		}
+	case requeueAfter > 0:
+		c.queue.Forget(path)
+		c.queue.AddAfter(path, requeueAfter)
+	default:
+		c.queue.Forget(path)
+	}
+	return true
+}
 
-		// 加载并处理配置文件
-		cfg, err := loadConfigFile(path)
-		if err != nil {
-			log.Printf("Error loading config file %s: %v", path, err)
-			return nil
+// syncPath 根据配置文件的当前状态调谐或清理其中的资源
+// 工作队列保证同一路径不会被并发处理，c.mu 只在访问资源索引时持有，
+// 以免长时间的调谐阻塞文件事件和漂移检查
+func (c *Controller) syncPath(ctx context.Context, path string) (time.Duration, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, c.removeFile(ctx, path)
 		}
+		return 0, err
+	}
+	return c.syncFile(ctx, path)
+}
 
-		// 查找对应的处理器
-		handler, exists := c.handlers[cfg.Kind]
-		if !exists {
-			log.Printf("No handler found for kind: %s", cfg.Kind)
-			return nil
+// syncFile 加载配置文件，内容自上次成功调谐后未变化时跳过
+func (c *Controller) syncFile(ctx context.Context, path string) (time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	c.mu.Lock()
+	previous, indexed := c.resources[path]
+	unchanged := indexed && previous.hash == hash
+	c.mu.Unlock()
+	if unchanged {
+		return 0, nil
+	}
+
+	// 加载并处理配置文件，格式错误需要修改文件后才能恢复，无需重试
+	cfgs, err := loadConfigFile(path, data)
+	if err != nil {
+		slog.Error("Failed to load config file", "path", path, "error", err)
+		return 0, nil
+	}
+
+	// 已由其他配置文件声明的资源不调谐，以免两个文件互相覆盖状态或清理对方的资源
+	owned, duplicates := c.claim(path, cfgs)
+	for _, cfg := range duplicates {
+		slog.Warn("Duplicate resource, skip reconciling", "kind", cfg.Kind, "name", cfg.Metadata.Name,
+			"path", path, "reason", c.conflict(path, cfg))
+	}
+
+	// spec 变化的资源代数加一，并在调谐前通知监听者配置的变化
+	for _, cfg := range owned {
+		c.resolveGeneration(cfg)
+		c.publish(path, cfg)
+	}
+
+	// 清理从文件中移除、被重命名或更换类型的资源
+	if indexed {
+		c.mu.Lock()
+		configs := previous.configs
+		c.mu.Unlock()
+		for _, old := range configs {
+			if !containsResource(owned, old) {
+				if err := c.deleteResource(ctx, path, old, true); err != nil {
+					return 0, err
+				}
+				c.release(path, old)
+			}
 		}
+	}
+	entry := &resourceEntry{configs: owned, duplicates: duplicates}
+	c.mu.Lock()
+	c.resources[path] = entry
+	c.mu.Unlock()
 
-		// 执行调谐
-		result, err := handler.Reconcile(ctx, cfg)
+	var (
+		requeueAfter time.Duration
+		errs         []error
+	)
+	for _, cfg := range owned {
+		// 已标记删除的资源只做清理
+		if cfg.Metadata.DeletionTime != "" {
+			if err := c.deleteResource(ctx, path, cfg, false); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		after, err := c.reconcileResource(ctx, path, cfg)
 		if err != nil {
-			log.Printf("Reconciliation error for %s: %v", path, err)
+			errs = append(errs, err)
+			continue
+		}
+		if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+			requeueAfter = after
+		}
+	}
+
+	if len(errs) == 0 && requeueAfter == 0 {
+		c.mu.Lock()
+		entry.hash = hash
+		c.mu.Unlock()
+	}
+	return requeueAfter, errors.Join(errs...)
+}
+
+// containsResource 判断资源列表中是否有与 cfg 类型和名称相同的资源
+func containsResource(cfgs []*config.ResourceConfig, cfg *config.ResourceConfig) bool {
+	for _, other := range cfgs {
+		if other.Kind == cfg.Kind && other.Metadata.Name == cfg.Metadata.Name {
+			return true
 		}
+	}
+	return false
+}
 
-		if result.Status != nil {
-			log.Printf("Reconciliation status for %s: %s", path, result.Status.Phase)
+// resourceKey 返回区分资源的类型和名称
+func resourceKey(cfg *config.ResourceConfig) string {
+	return cfg.Kind + "/" + cfg.Metadata.Name
+}
+
+// isExclusive 判断该类型的处理器是否只允许一个资源
+func (c *Controller) isExclusive(kind string) bool {
+	handler, ok := c.handlers[kind].(Exclusive)
+	return ok && handler.Exclusive()
+}
+
+// claim 将配置文件中的资源登记为由 path 声明
+// 返回的 duplicates 是已由其他配置文件声明的资源，以及只允许一个资源的类型中后声明的资源
+func (c *Controller) claim(path string, cfgs []*config.ResourceConfig) (owned, duplicates []*config.ResourceConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cfg := range cfgs {
+		if cfg.Metadata.Name != "" {
+			key := resourceKey(cfg)
+			if owner, ok := c.owners[key]; ok && owner != path {
+				duplicates = append(duplicates, cfg)
+				continue
+			}
+			if c.isExclusive(cfg.Kind) {
+				if holder, ok := c.exclusive[cfg.Kind]; ok && holder != key {
+					duplicates = append(duplicates, cfg)
+					continue
+				}
+				c.exclusive[cfg.Kind] = key
+			}
+			c.owners[key] = path
 		}
+		owned = append(owned, cfg)
+	}
+	return owned, duplicates
+}
+
+// release 在 path 中的资源被清理后释放声明，并重新处理因该资源而未被调谐的其他配置文件
+func (c *Controller) release(path string, cfg *config.ResourceConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := resourceKey(cfg)
+	if c.owners[key] != path {
+		return
+	}
+	delete(c.owners, key)
+	exclusive := c.exclusive[cfg.Kind] == key
+	if exclusive {
+		delete(c.exclusive, cfg.Kind)
+	}
+	for other, entry := range c.resources {
+		if other == path {
+			continue
+		}
+		for _, duplicate := range entry.duplicates {
+			if resourceKey(duplicate) == key || exclusive && duplicate.Kind == cfg.Kind {
+				entry.hash = ""
+				c.queue.Add(other)
+				break
+			}
+		}
+	}
+}
+
+// conflict 返回资源未被调谐的原因，资源没有与其他资源冲突时返回空
+func (c *Controller) conflict(path string, cfg *config.ResourceConfig) string {
+	if owner := c.duplicateOwner(path, cfg); owner != "" {
+		return fmt.Sprintf("%s %s is already declared in %s", cfg.Kind, cfg.Metadata.Name, owner)
+	}
+	if cfg.Metadata.Name == "" {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if holder, ok := c.exclusive[cfg.Kind]; ok && holder != resourceKey(cfg) {
+		return fmt.Sprintf("only one %s resource is allowed, %s is already declared in %s", cfg.Kind, holder, c.owners[holder])
+	}
+	return ""
+}
 
+// duplicateOwner 返回最先声明该资源的其他配置文件，资源不是重复声明时返回空
+func (c *Controller) duplicateOwner(path string, cfg *config.ResourceConfig) string {
+	if cfg.Metadata.Name == "" {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if owner := c.owners[resourceKey(cfg)]; owner != path {
+		return owner
+	}
+	return ""
+}
+
+// removeFile 清理已删除配置文件中的资源，清理失败时保留索引以便重试
+func (c *Controller) removeFile(ctx context.Context, path string) error {
+	c.mu.Lock()
+	entry, ok := c.resources[path]
+	var configs []*config.ResourceConfig
+	if ok {
+		configs = entry.configs
+	}
+	c.mu.Unlock()
+	if !ok {
 		return nil
-	})
+	}
+
+	for i, cfg := range configs {
+		if err := c.deleteResource(ctx, path, cfg, true); err != nil {
+			// 只保留尚未清理的资源
+			c.mu.Lock()
+			entry.configs = configs[i:]
+			c.mu.Unlock()
+			return err
+		}
+		c.release(path, cfg)
+	}
+	c.mu.Lock()
+	delete(c.resources, path)
+	c.mu.Unlock()
+	return nil
+}
+
+// isConfigFile 判断路径是否为需要处理的配置文件
+func (c *Controller) isConfigFile(path string) bool {
+	return configExts[filepath.Ext(path)] && !isHidden(path, c.configDir)
+}
 
+// reconcileResource 调谐单个资源并持久化其状态
+// 返回处理器要求的重新调谐时间；处理器不存在时无需重试，不返回错误
+func (c *Controller) reconcileResource(ctx context.Context, path string, cfg *config.ResourceConfig) (time.Duration, error) {
+	var (
+		result *ReconcileResult
+		err    error
+		start  = time.Now()
+	)
+
+	logger := resourceLogger(ctx, path, cfg)
+	ctx = utils.WithLogger(ctx, logger)
+
+	// 校验失败的资源需要修改配置文件后才能恢复，无需重试
+	if errs := ValidateResource(cfg); len(errs) > 0 {
+		logger.Warn("Invalid resource", "error", errs)
+		status := &config.ResourceStatus{
+			Phase:       config.PhaseFailed,
+			Reason:      "InvalidSpec",
+			Message:     errs.Error(),
+			FieldErrors: errs,
+		}
+		// 系统中仍是上次成功调谐的配置，保留它以便删除资源时清理
+		c.saveStatus(path, cfg, status, c.lastEffective(cfg))
+		observeReconcile(cfg.Kind, status, start)
+		return 0, nil
+	}
+
+	// 记录调谐过程中执行的命令
+	recorder := utils.NewRecorder(utils.RunnerFromContext(ctx))
+	ctx = utils.WithRunner(ctx, recorder)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	defer c.trackReconcile()()
+
+	// 查找对应的处理器
+	handler, exists := c.handlers[cfg.Kind]
+	if exists {
+		// 执行调谐
+		result, err = handler.Reconcile(ctx, cfg)
+	} else {
+		err = fmt.Errorf("no handler found for kind: %s", cfg.Kind)
+	}
+	if err == nil && ctx.Err() != nil {
+		// 处理器可能把被终止的命令当作普通的命令失败处理
+		err = fmt.Errorf("reconcile interrupted: %v", ctx.Err())
+	}
 	if err != nil {
-		log.Printf("Error walking config directory: %v", err)
+		logger.Error("Reconcile failed", "error", err)
+	}
+
+	if result == nil {
+		result = &ReconcileResult{}
+	}
+	status := result.Status
+	if status == nil {
+		status = &config.ResourceStatus{}
+	}
+	switch {
+	case !exists:
+		status.Phase = config.PhaseFailed
+		status.Reason = "NoHandler"
+		status.Message = err.Error()
+	case err != nil:
+		status.Phase = config.PhaseFailed
+		status.Reason = "ReconcileError"
+		status.Message = err.Error()
+	case status.Phase == "":
+		status.Phase = config.PhaseReady
 	}
+	status.Commands = recorder.Records()
+
+	logger.Info("Reconciled resource", "phase", status.Phase, "duration", time.Since(start))
+	c.saveStatus(path, cfg, status, result.Effective)
+	observeReconcile(cfg.Kind, status, start)
+
+	if !exists {
+		return 0, nil
+	}
+	return result.RequeueAfter, err
 }
 
-func loadConfigFile(path string) (*config.ResourceConfig, error) {
-	data, err := os.ReadFile(path)
+// deleteResource 调用处理器清理资源生成的文件
+// removed 为 true 表示配置文件已被删除，清理成功后同时删除其状态
+func (c *Controller) deleteResource(ctx context.Context, path string, cfg *config.ResourceConfig, removed bool) error {
+	start := time.Now()
+	logger := resourceLogger(ctx, path, cfg)
+	ctx = utils.WithLogger(ctx, logger)
+	recorder := utils.NewRecorder(utils.RunnerFromContext(ctx))
+	ctx = utils.WithRunner(ctx, recorder)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	defer c.trackReconcile()()
+
+	var err error
+	handler, exists := c.handlers[cfg.Kind]
+	if exists && cfg.Spec != nil {
+		err = handler.Cleanup(ctx, cfg)
+	}
 	if err != nil {
-		return nil, err
+		logger.Error("Cleanup failed", "error", err)
+	} else {
+		logger.Info("Cleaned up resource", "duration", time.Since(start))
 	}
 
-	var cfg config.ResourceConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	metrics.ObserveCommands(cfg.Kind, recorder.Records())
+
+	if removed && err == nil {
+		if cfg.Metadata.Name != "" {
+			if err := c.status.Delete(cfg.Kind, cfg.Metadata.Name); err != nil {
+				logger.Error("Failed to delete status", "error", err)
+			}
+			metrics.DeleteResource(cfg.Kind, cfg.Metadata.Name)
+		}
+		c.publishDeleted(path, cfg)
+		return nil
+	}
+
+	status := &config.ResourceStatus{Phase: config.PhaseDeleted, Commands: recorder.Records()}
+	var effective *config.ResourceConfig
+	if err != nil {
+		status.Phase = config.PhaseFailed
+		status.Reason = "CleanupError"
+		status.Message = err.Error()
+		// 保留配置以便下次启动时重试清理
+		effective = cfg
+	}
+	c.saveStatus(path, cfg, status, effective)
+	return err
+}
+
+// resourceLogger 返回带有资源字段的日志，处理器和执行的命令通过 context 使用它
+func resourceLogger(ctx context.Context, path string, cfg *config.ResourceConfig) *slog.Logger {
+	return utils.LoggerFromContext(ctx).With(
+		"kind", cfg.Kind,
+		"name", cfg.Metadata.Name,
+		"path", path,
+		"generation", cfg.Metadata.Generation,
+	)
+}
+
+// observeReconcile 按调谐后的阶段统计调谐次数、耗时和执行的命令
+func observeReconcile(kind string, status *config.ResourceStatus, start time.Time) {
+	metrics.ReconcileTotal.WithLabelValues(kind, status.Phase).Inc()
+	metrics.ReconcileDuration.WithLabelValues(kind, status.Phase).Observe(time.Since(start).Seconds())
+	metrics.ObserveCommands(kind, status.Commands)
+}
+
+// withTimeout 为单个资源的调谐或清理设置超时时间
+func (c *Controller) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// resolveGeneration 按状态中记录的 spec 设置资源的代数
+func (c *Controller) resolveGeneration(cfg *config.ResourceConfig) {
+	var state *ResourceState
+	if cfg.Metadata.Name != "" {
+		state, _ = c.status.Load(cfg.Kind, cfg.Metadata.Name)
+	}
+	resolveGeneration(cfg, state)
+}
+
+// lastEffective 返回资源最近一次保存的生效配置
+func (c *Controller) lastEffective(cfg *config.ResourceConfig) *config.ResourceConfig {
+	if cfg.Metadata.Name == "" {
+		return nil
+	}
+	state, err := c.status.Load(cfg.Kind, cfg.Metadata.Name)
+	if err != nil {
+		return nil
+	}
+	return state.Effective
+}
+
+// saveStatus 补全状态中的调谐时间和代数并持久化
+func (c *Controller) saveStatus(path string, cfg *config.ResourceConfig, status *config.ResourceStatus, effective *config.ResourceConfig) {
+	status.LastReconcileTime = time.Now().UTC().Format(time.RFC3339)
+	status.ObservedGeneration = cfg.Metadata.Generation
+
+	if cfg.Metadata.Name == "" {
+		slog.Warn("Resource has no name, skip saving status", "kind", cfg.Kind, "path", path)
+		return
 	}
 
-	return &cfg, nil
+	err := c.status.Save(&ResourceState{
+		Kind:       cfg.Kind,
+		Name:       cfg.Metadata.Name,
+		Path:       path,
+		Status:     status,
+		Effective:  effective,
+		Generation: cfg.Metadata.Generation,
+		SpecHash:   specHash(cfg.Spec),
+	})
+	if err != nil {
+		slog.Error("Failed to save status", "kind", cfg.Kind, "name", cfg.Metadata.Name, "error", err)
+		return
+	}
+	metrics.SetPhase(cfg.Kind, cfg.Metadata.Name, status.Phase)
+	c.publish(path, cfg)
+}
+
+// isHidden 判断配置目录中的路径是否为隐藏文件或目录（如状态目录、编辑器临时文件）
+func isHidden(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
 }
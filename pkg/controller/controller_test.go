@@ -0,0 +1,554 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/metrics"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/utils"
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// commandHandler 是执行固定命令的测试处理器
+type commandHandler struct{}
+
+func (h *commandHandler) Match(osInfo OSInfo) bool {
+	return true
+}
+
+func (h *commandHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*ReconcileResult, error) {
+	runner := utils.RunnerFromContext(ctx)
+	if _, err := runner.Run(ctx, "systemctl", "is-active", "chronyd"); err != nil {
+		return &ReconcileResult{Effective: cfg}, nil // 服务未运行，无需重新加载
+	}
+	if _, err := runner.Run(ctx, "chronyc", "reload", "sources"); err != nil {
+		return nil, err
+	}
+	return &ReconcileResult{Effective: cfg}, nil
+}
+
+func (h *commandHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	_, err := utils.RunnerFromContext(ctx).Run(ctx, "chronyc", "reload", "sources")
+	return err
+}
+
+func init() {
+	RegisterHandler("TimeConfiguration", &commandHandler{})
+}
+
+// newTestController 创建使用临时根目录和配置目录的控制器
+func newTestController(t *testing.T, runner utils.Runner) (*Controller, string) {
+	t.Helper()
+	root := testutil.NewRoot(t)
+	testutil.WriteFile(t, root, "/etc/os-release", "ID=debian\nVERSION_ID=\"12\"\n")
+	configDir := t.TempDir()
+
+	c, err := NewController(configDir, WithRoot(root.Root()), WithRunner(runner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, configDir
+}
+
+func writeResource(t *testing.T, configDir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(configDir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const timeResource = `{"kind": "TimeConfiguration", "metadata": {"name": "time", "generation": 1}, "spec": {}}`
+
+func TestReconcileRecordsCommands(t *testing.T) {
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"chronyc reload sources": {Output: "501 Not authorised", ExitCode: 1},
+	}}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json", timeResource)
+
+	if _, err := c.syncPath(c.handlerContext(), path); err == nil {
+		t.Fatal("expected reconcile error")
+	}
+
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseFailed {
+		t.Errorf("got phase %s, want %s", state.Status.Phase, config.PhaseFailed)
+	}
+
+	commands := state.Status.Commands
+	if len(commands) != 2 {
+		t.Fatalf("got %d command records, want 2: %+v", len(commands), commands)
+	}
+	if commands[0].ExitCode != 0 {
+		t.Errorf("got exit code %d for %q, want 0", commands[0].ExitCode, commands[0].Command)
+	}
+	last := commands[1]
+	if last.ExitCode != 1 || last.Output != "501 Not authorised" || last.Error == "" {
+		t.Errorf("unexpected record for failed command: %+v", last)
+	}
+}
+
+func TestCleanupRecordsCommands(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json", timeResource)
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	// 标记删除后只执行清理
+	writeResource(t, configDir, "time.json",
+		`{"kind": "TimeConfiguration", "metadata": {"name": "time", "generation": 2, "deletionTime": "2024-01-01T00:00:00Z"}, "spec": {}}`)
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseDeleted {
+		t.Errorf("got phase %s, want %s", state.Status.Phase, config.PhaseDeleted)
+	}
+	if len(state.Status.Commands) != 1 || state.Status.Commands[0].Command[0] != "chronyc" {
+		t.Errorf("unexpected cleanup command records: %+v", state.Status.Commands)
+	}
+}
+
+func TestMultiDocumentFile(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.yaml", `kind: TimeConfiguration
+metadata:
+  name: time-a
+spec: {}
+---
+kind: TimeConfiguration
+metadata:
+  name: time-b
+spec: {}
+`)
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"time-a", "time-b"} {
+		state, err := c.StatusStore().Load("TimeConfiguration", name)
+		if err != nil {
+			t.Fatalf("status of %s: %v", name, err)
+		}
+		if state.Status.Phase != config.PhaseReady {
+			t.Errorf("got phase %s for %s, want %s", state.Status.Phase, name, config.PhaseReady)
+		}
+	}
+
+	// 从文件中移除的资源被清理，其余资源保持不变
+	writeResource(t, configDir, "time.yaml", "kind: TimeConfiguration\nmetadata:\n  name: time-a\nspec: {}\n")
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.StatusStore().Load("TimeConfiguration", "time-b"); !os.IsNotExist(err) {
+		t.Errorf("expected status of time-b to be deleted, got %v", err)
+	}
+	if _, err := c.StatusStore().Load("TimeConfiguration", "time-a"); err != nil {
+		t.Errorf("status of time-a: %v", err)
+	}
+	if got := c.resources[path].configs; len(got) != 1 || got[0].Metadata.Name != "time-a" {
+		t.Errorf("unexpected indexed resources: %+v", got)
+	}
+}
+
+// timeSpec 是测试使用的带校验规则的 spec
+type timeSpec struct {
+	Timezone string `json:"timezone"`
+}
+
+func (s *timeSpec) Validate(path *validation.Path) validation.ErrorList {
+	if s.Timezone == "" {
+		return nil
+	}
+	return validation.ValidateTimezone(path.Child("timezone"), s.Timezone)
+}
+
+func TestInvalidSpec(t *testing.T) {
+	RegisterSpec("TimeConfiguration", &timeSpec{})
+	defer delete(specTypes, "TimeConfiguration")
+
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json",
+		`{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "Mars/Olympus"}}`)
+
+	// 校验失败时不调用处理器，也不重试
+	requeueAfter, err := c.syncPath(c.handlerContext(), path)
+	if err != nil || requeueAfter != 0 {
+		t.Fatalf("got requeueAfter=%s err=%v, want no retry", requeueAfter, err)
+	}
+	if got := runner.Commands(); len(got) != 0 {
+		t.Errorf("handler ran commands for an invalid resource: %q", got)
+	}
+
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseFailed || state.Status.Reason != "InvalidSpec" {
+		t.Errorf("got phase %s reason %s, want Failed InvalidSpec", state.Status.Phase, state.Status.Reason)
+	}
+	want := []string{"spec.timezone"}
+	fields := state.Status.FieldErrors
+	if len(fields) != len(want) || fields[0].Field != want[0] {
+		t.Errorf("got field errors %+v, want fields %v", fields, want)
+	}
+}
+
+func TestReconcileMetrics(t *testing.T) {
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"chronyc reload sources": {Output: "501 Not authorised", ExitCode: 1},
+	}}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json", timeResource)
+
+	failed := metrics.ReconcileTotal.WithLabelValues("TimeConfiguration", config.PhaseFailed)
+	executions := metrics.CommandExecutions.WithLabelValues("TimeConfiguration", "chronyc")
+	failures := metrics.CommandFailures.WithLabelValues("TimeConfiguration", "chronyc")
+	before := []float64{promtest.ToFloat64(failed), promtest.ToFloat64(executions), promtest.ToFloat64(failures)}
+
+	if _, err := c.syncPath(c.handlerContext(), path); err == nil {
+		t.Fatal("expected reconcile error")
+	}
+	after := []float64{promtest.ToFloat64(failed), promtest.ToFloat64(executions), promtest.ToFloat64(failures)}
+	for i, name := range []string{"reconcile_total", "command_executions_total", "command_failures_total"} {
+		if after[i]-before[i] != 1 {
+			t.Errorf("%s increased by %v, want 1", name, after[i]-before[i])
+		}
+	}
+	if got := promtest.ToFloat64(metrics.ResourcePhase.WithLabelValues("TimeConfiguration", "time", config.PhaseFailed)); got != 1 {
+		t.Errorf("got phase Failed = %v, want 1", got)
+	}
+
+	// 删除资源后不再导出其阶段
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), path); err == nil {
+		t.Fatal("expected cleanup error")
+	}
+	runner.Results = nil
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if n := metrics.ResourcePhase.DeletePartialMatch(prometheus.Labels{"kind": "TimeConfiguration", "name": "time"}); n != 0 {
+		t.Errorf("got %d phase series after deletion", n)
+	}
+}
+
+func TestStatusKeyedByKindAndName(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	path := writeResource(t, configDir, "shared.yaml", `kind: TimeConfiguration
+metadata:
+  name: shared
+spec: {}
+---
+kind: UnknownConfiguration
+metadata:
+  name: shared
+spec: {}
+`)
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	state, err := c.StatusStore().Load("TimeConfiguration", "shared")
+	if err != nil || state.Status.Phase != config.PhaseReady {
+		t.Fatalf("got status %+v, err %v, want %s", state, err, config.PhaseReady)
+	}
+	state, err = c.StatusStore().Load("UnknownConfiguration", "shared")
+	if err != nil || state.Status.Reason != "NoHandler" {
+		t.Fatalf("got status %+v, err %v, want NoHandler", state, err)
+	}
+
+	// 删除一种类型的资源不影响另一种类型的同名资源
+	writeResource(t, configDir, "shared.yaml", "kind: UnknownConfiguration\nmetadata:\n  name: shared\nspec: {}\n")
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.StatusStore().Load("TimeConfiguration", "shared"); !os.IsNotExist(err) {
+		t.Errorf("expected status of TimeConfiguration to be deleted, got %v", err)
+	}
+	if _, err := c.StatusStore().Load("UnknownConfiguration", "shared"); err != nil {
+		t.Errorf("status of UnknownConfiguration: %v", err)
+	}
+}
+
+func TestDuplicateResourceAcrossFiles(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	first := writeResource(t, configDir, "time.json", timeResource)
+	second := writeResource(t, configDir, "time-copy.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`)
+	for _, path := range []string{first, second} {
+		if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 后声明的资源不被调谐，也不覆盖先声明的资源的状态
+	if got := runner.Commands(); len(got) != 2 {
+		t.Errorf("got commands %q, want only the first file reconciled", got)
+	}
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Path != first || state.Status.Phase != config.PhaseReady {
+		t.Errorf("got status %+v from %s, want %s from %s", state.Status, state.Path, config.PhaseReady, first)
+	}
+	resources, err := c.ListResources("TimeConfiguration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resource := range resources {
+		if resource.Path == second && (resource.Status == nil || resource.Status.Reason != "DuplicateResource") {
+			t.Errorf("got status %+v for the duplicate, want DuplicateResource", resource.Status)
+		}
+	}
+
+	// 删除重复声明的文件不清理先声明的资源
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != 2 {
+		t.Errorf("unexpected commands after removing the duplicate: %q", got[2:])
+	}
+	if _, err := c.StatusStore().Load("TimeConfiguration", "time"); err != nil {
+		t.Errorf("status deleted with the duplicate: %v", err)
+	}
+
+	// 先声明的文件被删除后，由仍声明该资源的文件接管
+	writeResource(t, configDir, "time-copy.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`)
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), first); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := c.queue.Get(); path != second {
+		t.Fatalf("got queued path %s, want %s", path, second)
+	}
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	state, err = c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Path != second || state.Status.Phase != config.PhaseReady {
+		t.Errorf("got status %+v from %s, want %s from %s", state.Status, state.Path, config.PhaseReady, second)
+	}
+}
+
+// exclusiveHandler 是只允许一个资源的测试处理器
+type exclusiveHandler struct {
+	commandHandler
+}
+
+func (h *exclusiveHandler) Exclusive() bool {
+	return true
+}
+
+func TestExclusiveResource(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	c.handlers["TimeConfiguration"] = &exclusiveHandler{}
+	first := writeResource(t, configDir, "time-a.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-a"}, "spec": {}}`)
+	second := writeResource(t, configDir, "time-b.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-b"}, "spec": {}}`)
+	for _, path := range []string{first, second} {
+		if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 同类型的第二个资源不被调谐
+	if got := runner.Commands(); len(got) != 2 {
+		t.Errorf("got commands %q, want only the first resource reconciled", got)
+	}
+	resource, err := c.GetResource("TimeConfiguration", "time-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Status == nil || resource.Status.Reason != "DuplicateResource" {
+		t.Errorf("got status %+v for the second resource, want DuplicateResource", resource.Status)
+	}
+
+	// 删除第二个资源不清理第一个资源使用的文件
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != 2 {
+		t.Errorf("unexpected commands after removing the second resource: %q", got[2:])
+	}
+
+	// 第一个资源被删除后，由第二个资源接管
+	writeResource(t, configDir, "time-b.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-b"}, "spec": {}}`)
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), first); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := c.queue.Get(); path != second {
+		t.Fatalf("got queued path %s, want %s", path, second)
+	}
+	if _, err := c.syncPath(c.handlerContext(), second); err != nil {
+		t.Fatal(err)
+	}
+	state, err := c.StatusStore().Load("TimeConfiguration", "time-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseReady {
+		t.Errorf("got phase %s, want %s", state.Status.Phase, config.PhaseReady)
+	}
+}
+
+func TestInvalidResourceName(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json", `{"kind": "TimeConfiguration", "metadata": {"name": "../time"}, "spec": {}}`)
+
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if len(runner.Commands()) != 0 {
+		t.Errorf("resource with invalid name was reconciled: %v", runner.Commands())
+	}
+	states, err := c.StatusStore().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 0 {
+		t.Errorf("got status %+v for invalid name", states)
+	}
+	if _, err := os.Stat(filepath.Join(c.statusDir, "TimeConfiguration", "..", "time.json")); !os.IsNotExist(err) {
+		t.Errorf("status written outside its directory: %v", err)
+	}
+}
+
+func TestStatusStoreMigratesLegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "time.json")
+	if err := os.WriteFile(legacy, []byte(`{"kind": "TimeConfiguration", "name": "time", "path": "/etc/nix-operator/time.json"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewStatusStore(dir)
+	states, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].Name != "time" {
+		t.Fatalf("got states %+v", states)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy status not removed: %v", err)
+	}
+	if _, err := store.Load("TimeConfiguration", "time"); err != nil {
+		t.Errorf("migrated status: %v", err)
+	}
+}
+
+func TestSyncPathOnlyReconcilesChangedFile(t *testing.T) {
+	runner := &testutil.FakeRunner{}
+	c, configDir := newTestController(t, runner)
+	pathA := writeResource(t, configDir, "time-a.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-a"}, "spec": {}}`)
+	pathB := writeResource(t, configDir, "time-b.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-b"}, "spec": {}}`)
+	for _, path := range []string{pathA, pathB} {
+		if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconciled := len(runner.Commands())
+
+	// 文件事件只将发生变化的文件加入队列
+	writeResource(t, configDir, "time-a.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-a"}, "spec": {"timezone": "UTC"}}`)
+	c.enqueuePath(pathA)
+	if n := c.queue.Len(); n != 1 {
+		t.Fatalf("got %d queued paths, want 1", n)
+	}
+	if path, _ := c.queue.Get(); path != pathA {
+		t.Errorf("got queued path %s, want %s", path, pathA)
+	}
+
+	// 内容未变化的文件跳过调谐
+	for _, path := range []string{pathA, pathB} {
+		if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := runner.Commands()[reconciled:]; len(got) != reconciled/2 {
+		t.Errorf("got commands %q, want only time-a reconciled", got)
+	}
+	state, err := c.StatusStore().Load("TimeConfiguration", "time-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Generation != 2 {
+		t.Errorf("got generation %d for time-a, want 2", state.Generation)
+	}
+}
+
+func TestSyncPathDoesNotBlockEvents(t *testing.T) {
+	runner := newBlockingRunner()
+	c, configDir := newTestController(t, runner)
+	path := writeResource(t, configDir, "time.json", timeResource)
+	other := writeResource(t, configDir, "time-b.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time-b"}, "spec": {}}`)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.syncPath(c.handlerContext(), path)
+		done <- err
+	}()
+	<-runner.started
+
+	// 调谐进行中时文件事件和漂移检查不被阻塞
+	enqueued := make(chan struct{})
+	go func() {
+		c.enqueuePath(other)
+		c.enqueueAll()
+		c.resync(c.handlerContext())
+		close(enqueued)
+	}()
+	select {
+	case <-enqueued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("enqueue blocked by an in-flight reconcile")
+	}
+
+	close(runner.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
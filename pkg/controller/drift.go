@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/metrics"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// RenderedFile 是处理器期望写入系统的文件
+type RenderedFile struct {
+	Path    string
+	Content []byte
+	Mode    os.FileMode
+}
+
+// Renderer 由生成配置文件的处理器实现，用于在不修改系统的情况下计算期望的文件内容
+type Renderer interface {
+	Render(ctx context.Context, config *config.ResourceConfig) ([]RenderedFile, error)
+}
+
+// DetectDrift 比较渲染出的期望文件与系统中的当前文件
+func DetectDrift(ctx context.Context, files []RenderedFile) ([]config.FileDrift, error) {
+	fsys := utils.FSFromContext(ctx)
+	var drift []config.FileDrift
+	for _, file := range files {
+		current, err := fsys.ReadFile(file.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %v", file.Path, err)
+		}
+		if bytes.Equal(current, file.Content) {
+			continue
+		}
+		drift = append(drift, config.FileDrift{
+			Path: file.Path,
+			Diff: utils.UnifiedDiff(file.Path+" (desired)", file.Path+" (current)", file.Content, current),
+		})
+	}
+	return drift, nil
+}
+
+// resync 检查所有已成功调谐的资源是否被手工修改，按资源的漂移策略报告或纠正
+func (c *Controller) resync(ctx context.Context) {
+	// 渲染期间不持有锁，以免阻塞文件事件和工作队列
+	type indexed struct {
+		path    string
+		entry   *resourceEntry
+		configs []*config.ResourceConfig
+	}
+	var entries []indexed
+	c.mu.Lock()
+	for path, entry := range c.resources {
+		// 未成功调谐的资源由工作队列负责重试
+		if entry.hash != "" {
+			entries = append(entries, indexed{path, entry, entry.configs})
+		}
+	}
+	c.mu.Unlock()
+
+	for _, item := range entries {
+		path, entry := item.path, item.entry
+		for _, cfg := range item.configs {
+			if cfg.Metadata.DeletionTime != "" {
+				continue
+			}
+
+			renderer, ok := c.handlers[cfg.Kind].(Renderer)
+			if !ok {
+				continue
+			}
+			// 校验或调谐失败的资源渲染出的不是系统中生效的配置，由修改配置文件或重试恢复
+			if state, err := c.status.Load(cfg.Kind, cfg.Metadata.Name); err == nil &&
+				state.Status != nil && state.Status.Phase == config.PhaseFailed {
+				continue
+			}
+
+			logger := resourceLogger(ctx, path, cfg)
+			files, err := renderer.Render(utils.WithLogger(ctx, logger), cfg)
+			if err != nil {
+				logger.Error("Failed to render files for drift detection", "error", err)
+				continue
+			}
+			drift, err := DetectDrift(ctx, files)
+			if err != nil {
+				logger.Error("Failed to detect drift", "error", err)
+				continue
+			}
+
+			if len(drift) > 0 {
+				logger.Warn("Detected drift", "files", len(drift))
+				metrics.DriftDetections.WithLabelValues(cfg.Kind).Inc()
+				if cfg.Metadata.Annotations[config.AnnotationDriftPolicy] == config.DriftPolicyCorrect {
+					// 清除摘要使工作队列重新应用文件中的期望配置
+					c.mu.Lock()
+					if c.resources[path] == entry {
+						entry.hash = ""
+					}
+					c.mu.Unlock()
+					c.queue.Add(path)
+					continue
+				}
+			}
+			c.updateDrift(cfg, drift)
+		}
+	}
+}
+
+// updateDrift 将漂移检查结果写入资源状态
+// 调谐失败的资源和状态已对应其他代数的资源保持不变，以免覆盖失败原因或新的调谐结果
+func (c *Controller) updateDrift(cfg *config.ResourceConfig, drift []config.FileDrift) {
+	if cfg.Metadata.Name == "" {
+		return
+	}
+	err := c.status.Update(cfg.Kind, cfg.Metadata.Name, func(state *ResourceState) bool {
+		status := state.Status
+		if status == nil || status.Phase == config.PhaseFailed || state.Generation != cfg.Metadata.Generation {
+			return false
+		}
+		if len(status.Drift) == 0 && len(drift) == 0 {
+			return false
+		}
+
+		status.Drift = drift
+		switch {
+		case len(drift) > 0:
+			status.Reason = "DriftDetected"
+			status.Message = fmt.Sprintf("%d file(s) differ from the desired configuration", len(drift))
+		case status.Reason == "DriftDetected":
+			status.Reason = ""
+			status.Message = ""
+		}
+		return true
+	})
+	if err != nil && !os.IsNotExist(err) {
+		slog.Error("Failed to save status", "kind", cfg.Kind, "name", cfg.Metadata.Name, "error", err)
+	}
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/utils"
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+const driftFile = "/etc/drift.conf"
+
+// fileHandler 是将 spec 写入文件的测试处理器
+type fileHandler struct{}
+
+func (h *fileHandler) Match(osInfo OSInfo) bool {
+	return true
+}
+
+func (h *fileHandler) Render(ctx context.Context, cfg *config.ResourceConfig) ([]RenderedFile, error) {
+	content := append([]byte(config.CommentHeader), cfg.Spec...)
+	return []RenderedFile{{Path: driftFile, Content: content, Mode: 0644}}, nil
+}
+
+func (h *fileHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*ReconcileResult, error) {
+	files, err := h.Render(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	fsys := utils.FSFromContext(ctx)
+	if current, err := fsys.ReadFile(driftFile); err == nil && string(current) == string(files[0].Content) {
+		return &ReconcileResult{Effective: cfg}, nil
+	}
+	if err := fsys.WriteFile(driftFile, files[0].Content, files[0].Mode); err != nil {
+		return nil, err
+	}
+	return &ReconcileResult{Effective: cfg}, nil
+}
+
+func (h *fileHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	_, err := utils.RemoveGeneratedFile(utils.FSFromContext(ctx), driftFile)
+	return err
+}
+
+// newDriftController 创建调谐了一个生成文件的资源的控制器
+func newDriftController(t *testing.T, policy string) (*Controller, string) {
+	t.Helper()
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	c.handlers["HostsConfiguration"] = &fileHandler{}
+	if err := c.fs.MkdirAll("/etc", 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := writeResource(t, configDir, "drift.json", fmt.Sprintf(
+		`{"kind": "HostsConfiguration", "metadata": {"name": "drift", "annotations": {%q: %q}}, "spec": {"hosts": []}}`,
+		config.AnnotationDriftPolicy, policy))
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	return c, path
+}
+
+func loadDriftStatus(t *testing.T, c *Controller) *config.ResourceStatus {
+	t.Helper()
+	state, err := c.StatusStore().Load("HostsConfiguration", "drift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state.Status
+}
+
+func TestResyncReportsDrift(t *testing.T) {
+	c, _ := newDriftController(t, config.DriftPolicyReport)
+	desired, err := c.fs.ReadFile(driftFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未被修改的文件不报告漂移
+	c.resync(c.handlerContext())
+	if status := loadDriftStatus(t, c); len(status.Drift) != 0 || status.Reason != "" {
+		t.Fatalf("got status %+v without drift", status)
+	}
+
+	testutil.WriteFile(t, c.fs, driftFile, "edited by hand\n")
+	c.resync(c.handlerContext())
+	status := loadDriftStatus(t, c)
+	if len(status.Drift) != 1 || status.Drift[0].Path != driftFile || status.Reason != "DriftDetected" {
+		t.Errorf("got status %+v, want drift of %s reported", status, driftFile)
+	}
+	if status.Phase != config.PhaseReady {
+		t.Errorf("got phase %s, want %s", status.Phase, config.PhaseReady)
+	}
+	// report 策略不修改文件
+	if n := c.queue.Len(); n != 0 {
+		t.Errorf("got %d queued paths, want none for the report policy", n)
+	}
+	if current, _ := c.fs.ReadFile(driftFile); string(current) != "edited by hand\n" {
+		t.Errorf("file corrected under the report policy:\n%s", current)
+	}
+
+	// 文件恢复后清除漂移
+	testutil.WriteFile(t, c.fs, driftFile, string(desired))
+	c.resync(c.handlerContext())
+	if status := loadDriftStatus(t, c); len(status.Drift) != 0 || status.Reason != "" || status.Message != "" {
+		t.Errorf("got status %+v, want drift cleared", status)
+	}
+}
+
+func TestResyncCorrectsDrift(t *testing.T) {
+	c, path := newDriftController(t, config.DriftPolicyCorrect)
+	desired, err := c.fs.ReadFile(driftFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 仅格式不同的文件同样被重写
+	for _, edited := range []string{"edited by hand\n", string(desired) + "\n"} {
+		testutil.WriteFile(t, c.fs, driftFile, edited)
+		c.resync(c.handlerContext())
+		if n := c.queue.Len(); n != 1 {
+			t.Fatalf("got %d queued paths, want %s requeued", n, path)
+		}
+		if !c.processNextItem(c.handlerContext()) {
+			t.Fatal("queue shut down")
+		}
+		if current, _ := c.fs.ReadFile(driftFile); string(current) != string(desired) {
+			t.Errorf("got file %q after correction, want %q", current, desired)
+		}
+
+		// 纠正后不再重新入队
+		c.resync(c.handlerContext())
+		if n := c.queue.Len(); n != 0 {
+			t.Errorf("got %d queued paths after correction", n)
+		}
+		if status := loadDriftStatus(t, c); len(status.Drift) != 0 || status.Reason != "" {
+			t.Errorf("got status %+v after correction", status)
+		}
+	}
+}
+
+// hostsSpec 是漂移测试资源的 spec，未知字段使校验失败
+type hostsSpec struct {
+	Hosts []string `json:"hosts"`
+}
+
+func (s *hostsSpec) Validate(path *validation.Path) validation.ErrorList {
+	return nil
+}
+
+func TestResyncSkipsInvalidSpec(t *testing.T) {
+	RegisterSpec("HostsConfiguration", &hostsSpec{})
+	defer delete(specTypes, "HostsConfiguration")
+
+	c, path := newDriftController(t, config.DriftPolicyCorrect)
+	desired, err := c.fs.ReadFile(driftFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeResource(t, filepath.Dir(path), filepath.Base(path), fmt.Sprintf(
+		`{"kind": "HostsConfiguration", "metadata": {"name": "drift", "annotations": {%q: %q}}, "spec": {"hosts": [], "bogus": true}}`,
+		config.AnnotationDriftPolicy, config.DriftPolicyCorrect))
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	status := loadDriftStatus(t, c)
+	if status.Phase != config.PhaseFailed || status.Reason != "InvalidSpec" {
+		t.Fatalf("got status %+v, want Failed InvalidSpec", status)
+	}
+
+	// 校验失败的资源不检查漂移，也不重新入队
+	testutil.WriteFile(t, c.fs, driftFile, "edited by hand\n")
+	for i := 0; i < 2; i++ {
+		c.resync(c.handlerContext())
+	}
+	if n := c.queue.Len(); n != 0 {
+		t.Errorf("got %d queued paths, want the invalid resource not requeued", n)
+	}
+	if got := loadDriftStatus(t, c); got.Reason != "InvalidSpec" || got.Message != status.Message || len(got.Drift) != 0 {
+		t.Errorf("got status %+v, want the InvalidSpec status kept", got)
+	}
+
+	// 修正配置后恢复漂移检查
+	writeResource(t, filepath.Dir(path), filepath.Base(path), fmt.Sprintf(
+		`{"kind": "HostsConfiguration", "metadata": {"name": "drift", "annotations": {%q: %q}}, "spec": {"hosts": []}}`,
+		config.AnnotationDriftPolicy, config.DriftPolicyCorrect))
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := c.fs.ReadFile(driftFile); string(current) != string(desired) {
+		t.Errorf("got file %q after fixing the spec, want %q", current, desired)
+	}
+	if status := loadDriftStatus(t, c); status.Phase != config.PhaseReady {
+		t.Errorf("got status %+v, want %s", status, config.PhaseReady)
+	}
+}
+
+func TestUpdateDriftKeepsFailedStatus(t *testing.T) {
+	c, path := newDriftController(t, config.DriftPolicyReport)
+	cfg := c.resources[path].configs[0]
+	c.saveStatus(path, cfg, &config.ResourceStatus{Phase: config.PhaseFailed, Reason: "ReconcileError", Message: "boom"}, nil)
+
+	c.updateDrift(cfg, []config.FileDrift{{Path: driftFile}})
+	if status := loadDriftStatus(t, c); status.Reason != "ReconcileError" || status.Message != "boom" || len(status.Drift) != 0 {
+		t.Errorf("got status %+v, want the failure kept", status)
+	}
+}
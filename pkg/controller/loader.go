@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// configExts 是支持的资源文件扩展名
+var configExts = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// loadConfigFile 解析资源文件中的所有资源
+// JSON 文件包含一个资源，YAML 文件可以用 --- 分隔多个资源
+// 错误信息以 path:line:column 开头，指向出错的位置
+func loadConfigFile(path string, data []byte) ([]*config.ResourceConfig, error) {
+	var (
+		cfgs []*config.ResourceConfig
+		err  error
+	)
+	if filepath.Ext(path) == ".json" {
+		cfgs, err = loadJSON(path, data)
+	} else {
+		cfgs, err = loadYAML(path, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, cfg := range cfgs {
+		key := cfg.Kind + "/" + cfg.Metadata.Name
+		if cfg.Metadata.Name != "" && seen[key] {
+			return nil, fmt.Errorf("%s: duplicate resource %s", path, key)
+		}
+		seen[key] = true
+	}
+	return cfgs, nil
+}
+
+func loadJSON(path string, data []byte) ([]*config.ResourceConfig, error) {
+	var cfg config.ResourceConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			line, column := position(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %v", path, line, column, syntaxErr)
+		case errors.As(err, &typeErr):
+			// JSON 也是合法的 YAML，通过 YAML 节点定位字段的起始位置
+			var doc yaml.Node
+			if yaml.Unmarshal(data, &doc) == nil && len(doc.Content) > 0 {
+				field := lookupNode(doc.Content[0], typeErr.Field)
+				return nil, fmt.Errorf("%s:%d:%d: %s", path, field.Line, field.Column, typeErrorMessage(typeErr))
+			}
+			line, column := position(data, typeErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, line, column, typeErrorMessage(typeErr))
+		}
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return []*config.ResourceConfig{&cfg}, nil
+}
+
+// position 将字节偏移转换为从 1 开始的行号和列号
+func position(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func loadYAML(path string, data []byte) ([]*config.ResourceConfig, error) {
+	var cfgs []*config.ResourceConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, yamlError(path, err)
+		}
+		// 跳过空文档
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		cfg, err := decodeYAMLResource(path, doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, nil
+}
+
+// decodeYAMLResource 通过 JSON 解码资源，使 YAML 与 JSON 资源的解析规则一致
+func decodeYAMLResource(path string, node *yaml.Node) (*config.ResourceConfig, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d:%d: resource must be a mapping", path, node.Line, node.Column)
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return nil, yamlError(path, err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d:%d: %v", path, node.Line, node.Column, err)
+	}
+
+	var cfg config.ResourceConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			field := lookupNode(node, typeErr.Field)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, field.Line, field.Column, typeErrorMessage(typeErr))
+		}
+		return nil, fmt.Errorf("%s:%d:%d: %v", path, node.Line, node.Column, err)
+	}
+	return &cfg, nil
+}
+
+// lookupNode 按以 . 分隔的字段路径查找节点，找不到时返回最近的上级节点
+func lookupNode(node *yaml.Node, field string) *yaml.Node {
+	if field == "" {
+		return node
+	}
+	for _, key := range strings.Split(field, ".") {
+		if node.Kind != yaml.MappingNode {
+			return node
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+func typeErrorMessage(err *json.UnmarshalTypeError) string {
+	if err.Field == "" {
+		return fmt.Sprintf("cannot use %s as %s", err.Value, err.Type)
+	}
+	return fmt.Sprintf("%s: cannot use %s as %s", err.Field, err.Value, err.Type)
+}
+
+// yamlLinePrefix 匹配 yaml.v3 错误信息中的行号
+var yamlLinePrefix = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError 将 yaml.v3 的错误整理为 path:line: message 的形式
+func yamlError(path string, err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		err = errors.New(typeErr.Errors[0])
+	}
+	if m := yamlLinePrefix.FindStringSubmatch(err.Error()); m != nil {
+		return fmt.Errorf("%s:%s: %s", path, m[1], m[2])
+	}
+	return fmt.Errorf("%s: %v", path, err)
+}
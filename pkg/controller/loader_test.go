@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		data  string
+		kinds []string
+		names []string
+	}{
+		{
+			name:  "json",
+			path:  "hosts.json",
+			data:  `{"kind": "HostsConfiguration", "metadata": {"name": "hosts"}, "spec": {"hosts": []}}`,
+			kinds: []string{"HostsConfiguration"},
+			names: []string{"hosts"},
+		},
+		{
+			name: "yaml multi-document",
+			path: "system.yaml",
+			data: `apiVersion: sysconfig.operator/v1
+kind: HostsConfiguration
+metadata:
+  name: hosts
+spec:
+  hosts:
+    - ip: 192.168.1.10
+      hostnames: [host1]
+---
+# 空文档会被跳过
+---
+kind: TimeConfiguration
+metadata:
+  name: time
+  deletionTime: 2024-01-01T00:00:00Z
+spec:
+  timezone: Asia/Shanghai
+`,
+			kinds: []string{"HostsConfiguration", "TimeConfiguration"},
+			names: []string{"hosts", "time"},
+		},
+		{
+			name:  "yml",
+			path:  "udev.yml",
+			data:  "kind: UdevConfiguration\nmetadata:\n  name: udev\nspec:\n  rules: []\n",
+			kinds: []string{"UdevConfiguration"},
+			names: []string{"udev"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgs, err := loadConfigFile(tt.path, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cfgs) != len(tt.kinds) {
+				t.Fatalf("got %d resources, want %d", len(cfgs), len(tt.kinds))
+			}
+			for i, cfg := range cfgs {
+				if cfg.Kind != tt.kinds[i] || cfg.Metadata.Name != tt.names[i] {
+					t.Errorf("resource %d is %s/%s, want %s/%s", i, cfg.Kind, cfg.Metadata.Name, tt.kinds[i], tt.names[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileYAMLSpec(t *testing.T) {
+	data := "kind: TimeConfiguration\nmetadata:\n  name: time\n  deletionTime: 2024-01-01T00:00:00Z\nspec:\n  ntp:\n    enable: true\n"
+	cfgs, err := loadConfigFile("time.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfgs[0].Metadata.DeletionTime; got != "2024-01-01T00:00:00Z" {
+		t.Errorf("got deletionTime %q", got)
+	}
+	if got := string(cfgs[0].Spec); got != `{"ntp":{"enable":true}}` {
+		t.Errorf("got spec %s", got)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+		want string
+	}{
+		{
+			name: "json syntax",
+			path: "hosts.json",
+			data: "{\n  \"kind\": \"HostsConfiguration\",\n  \"metadata\": {\"name\": \"hosts\"\n}",
+			want: "hosts.json:4:2: unexpected end of JSON input",
+		},
+		{
+			name: "json type",
+			path: "hosts.json",
+			data: "{\n  \"kind\": \"HostsConfiguration\",\n  \"metadata\": {\"generation\": \"one\"}\n}",
+			want: "hosts.json:3:30: metadata.generation: cannot use string as int",
+		},
+		{
+			name: "yaml syntax",
+			path: "hosts.yaml",
+			data: "kind: HostsConfiguration\nmetadata:\n\tname: hosts\n",
+			want: "hosts.yaml:3: found character that cannot start any token",
+		},
+		{
+			name: "yaml type",
+			path: "hosts.yaml",
+			data: "kind: HostsConfiguration\nmetadata:\n  name: hosts\n  generation: one\n",
+			want: "hosts.yaml:4:15: metadata.generation: cannot use string as int",
+		},
+		{
+			name: "yaml second document",
+			path: "system.yaml",
+			data: "kind: HostsConfiguration\nmetadata:\n  name: hosts\n---\n- kind: TimeConfiguration\n",
+			want: "system.yaml:5:1: resource must be a mapping",
+		},
+		{
+			name: "yaml duplicate key",
+			path: "hosts.yaml",
+			data: "kind: HostsConfiguration\nkind: TimeConfiguration\n",
+			want: `hosts.yaml:2: mapping key "kind" already defined at line 1`,
+		},
+		{
+			name: "duplicate resource",
+			path: "system.yaml",
+			data: "kind: HostsConfiguration\nmetadata:\n  name: hosts\n---\nkind: HostsConfiguration\nmetadata:\n  name: hosts\n",
+			want: "system.yaml: duplicate resource HostsConfiguration/hosts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfigFile(tt.path, []byte(tt.data))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchdogGrace 是调谐超时后等待处理器退出的时间，超过后认为工作循环已卡住
+const watchdogGrace = 30 * time.Second
+
+// trackReconcile 记录正在调谐的资源的开始时间，返回的函数在调谐结束时调用
+func (c *Controller) trackReconcile() func() {
+	c.busySince.Store(time.Now().UnixNano())
+	return func() { c.busySince.Store(0) }
+}
+
+// idleTimeout 返回工作循环空闲等待的最长时间，未启用看门狗时一直等待
+func (c *Controller) idleTimeout() time.Duration {
+	if c.notifier == nil || c.watchdog <= 0 {
+		return 0
+	}
+	return c.watchdog / 2
+}
+
+// stalled 判断工作循环是否已停止推进：最近一次循环早于看门狗超时，
+// 且不在一个尚未超过调谐超时的调谐中；未设置调谐超时时调谐期间不放宽
+func (c *Controller) stalled() bool {
+	if time.Since(time.Unix(0, c.lastLoop.Load())) < c.watchdog {
+		return false
+	}
+	since := c.busySince.Load()
+	if since == 0 || c.timeout <= 0 {
+		return true
+	}
+	return time.Since(time.Unix(0, since)) > c.timeout+watchdogGrace
+}
+
+// runWatchdog 在工作循环仍在推进时按看门狗超时的一半发送 WATCHDOG=1，直到 stop 被关闭
+// shutdown 关闭后工作循环不再取出队列，等待调谐完成的时间由 drainTimeout 限制，期间始终发送
+func (c *Controller) runWatchdog(shutdown, stop <-chan struct{}) {
+	ticker := time.NewTicker(c.watchdog / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if c.stalled() && !isClosed(shutdown) {
+				slog.Warn("Controller loop is stuck, skipping watchdog notification")
+				continue
+			}
+			if err := c.notifier.Notify("WATCHDOG=1"); err != nil {
+				slog.Warn("Failed to send watchdog notification", "error", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// isClosed 判断 channel 是否已关闭
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// notifyStatus 向 systemd 报告资源阶段的汇总，内容变化时才发送
+// idle 为 true 表示工作队列已处理完，第一次处理完时同时报告 READY=1
+func (c *Controller) notifyStatus(idle bool) {
+	if c.notifier == nil {
+		return
+	}
+
+	var state []string
+	if idle && !c.ready {
+		c.ready = true
+		state = append(state, "READY=1")
+	}
+	status := c.statusSummary()
+	if status != c.lastStatus {
+		c.lastStatus = status
+		state = append(state, "STATUS="+status)
+	}
+	if len(state) == 0 {
+		return
+	}
+	if err := c.notifier.Notify(state...); err != nil {
+		slog.Warn("Failed to notify systemd", "error", err)
+	}
+}
+
+// statusSummary 按阶段统计资源数量，如 "3 resources: 1 Failed, 2 Ready"
+func (c *Controller) statusSummary() string {
+	states, err := c.status.List()
+	if err != nil {
+		return fmt.Sprintf("Error listing status: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, state := range states {
+		phase := "Unknown"
+		if state.Status != nil && state.Status.Phase != "" {
+			phase = state.Status.Phase
+		}
+		counts[phase]++
+	}
+	phases := make([]string, 0, len(counts))
+	for phase := range counts {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+	for i, phase := range phases {
+		phases[i] = fmt.Sprintf("%d %s", counts[phase], phase)
+	}
+
+	summary := fmt.Sprintf("%d resources", len(states))
+	if len(phases) > 0 {
+		summary += ": " + strings.Join(phases, ", ")
+	}
+	if !c.ready {
+		summary = "Reconciling " + summary
+	}
+	return summary
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// Plan 描述调谐资源时将写入的文件和将执行的命令
+type Plan struct {
+	Files    []RenderedFile
+	Commands [][]string // 每条命令的第一个元素是可执行文件
+}
+
+// Planner 由支持预览变更的处理器实现，Plan 不得修改系统或执行命令
+type Planner interface {
+	Plan(ctx context.Context, config *config.ResourceConfig) (*Plan, error)
+}
+
+// FileChange 是期望文件与当前文件的差异
+type FileChange struct {
+	Path string
+	Diff string // 从当前内容到期望内容的统一差异格式文本
+}
+
+// ResourcePlan 是单个资源的变更预览
+type ResourcePlan struct {
+	Kind     string
+	Name     string
+	Path     string // 资源所在的配置文件
+	Changes  []FileChange
+	Commands [][]string
+	Note     string // 无法预览时的说明
+	Err      error
+}
+
+// NewPlan 根据渲染出的文件生成预览，只保留与当前文件不同的文件
+func NewPlan(ctx context.Context, files []RenderedFile) (*Plan, error) {
+	fsys := utils.FSFromContext(ctx)
+	plan := &Plan{}
+	for _, file := range files {
+		changed, err := fileChanged(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			plan.Files = append(plan.Files, file)
+		}
+	}
+	return plan, nil
+}
+
+func fileChanged(fsys utils.FS, file RenderedFile) (bool, error) {
+	current, err := fsys.ReadFile(file.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %v", file.Path, err)
+	}
+	return !bytes.Equal(current, file.Content), nil
+}
+
+// Plan 预览配置目录中所有资源的变更，不写入文件也不执行命令
+func (c *Controller) Plan(ctx context.Context) ([]ResourcePlan, error) {
+	ctx = utils.WithFS(ctx, c.fs)
+
+	var paths []string
+	err := filepath.Walk(c.configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if isHidden(path, c.configDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if c.isConfigFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk config directory: %v", err)
+	}
+	sort.Strings(paths)
+
+	var plans []ResourcePlan
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			plans = append(plans, ResourcePlan{Path: path, Err: err})
+			continue
+		}
+		cfgs, err := loadConfigFile(path, data)
+		if err != nil {
+			plans = append(plans, ResourcePlan{Path: path, Err: err})
+			continue
+		}
+		for _, cfg := range cfgs {
+			plans = append(plans, c.planResource(ctx, path, cfg))
+		}
+	}
+	return plans, nil
+}
+
+func (c *Controller) planResource(ctx context.Context, path string, cfg *config.ResourceConfig) ResourcePlan {
+	result := ResourcePlan{
+		Kind: cfg.Kind,
+		Name: cfg.Metadata.Name,
+		Path: path,
+	}
+
+	if cfg.Metadata.DeletionTime != "" {
+		result.Note = "marked for deletion, cleanup is not planned"
+		return result
+	}
+
+	if errs := ValidateResource(cfg); len(errs) > 0 {
+		result.Err = errs
+		return result
+	}
+
+	handler, exists := c.handlers[cfg.Kind]
+	if !exists {
+		result.Err = fmt.Errorf("no handler found for kind: %s", cfg.Kind)
+		return result
+	}
+	planner, ok := handler.(Planner)
+	if !ok {
+		result.Note = "handler does not support planning"
+		return result
+	}
+
+	// 处理器在预览时执行的命令（如检查服务状态）只记录不执行
+	runner := &utils.DryRunner{}
+	plan, err := planner.Plan(utils.WithRunner(ctx, runner), cfg)
+	for _, command := range runner.Commands() {
+		slog.Debug("Skipped command while planning", "kind", cfg.Kind, "name", cfg.Metadata.Name, "command", strings.Join(command, " "))
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	fsys := utils.FSFromContext(ctx)
+	for _, file := range plan.Files {
+		current, err := fsys.ReadFile(file.Path)
+		if err != nil && !os.IsNotExist(err) {
+			result.Err = fmt.Errorf("failed to read %s: %v", file.Path, err)
+			return result
+		}
+		result.Changes = append(result.Changes, FileChange{
+			Path: file.Path,
+			Diff: utils.UnifiedDiff(file.Path, file.Path+" (planned)", current, file.Content),
+		})
+	}
+	result.Commands = plan.Commands
+	return result
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// Plan 在服务未运行时预览文件的变更，用于验证预览不会执行命令
+func (h *fileHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*Plan, error) {
+	if _, err := utils.RunnerFromContext(ctx).Run(ctx, "systemctl", "is-active", "drift"); err == nil {
+		return &Plan{}, nil
+	}
+	files, err := h.Render(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := NewPlan(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Files) > 0 {
+		plan.Commands = [][]string{{"systemctl", "reload", "drift"}}
+	}
+	return plan, nil
+}
+
+func TestPlanDoesNotChangeSystem(t *testing.T) {
+	// 未注入执行器的处理器会使用 HostRunner
+	runner := &testutil.FakeRunner{}
+	hostRunner := utils.HostRunner
+	utils.HostRunner = runner
+	defer func() { utils.HostRunner = hostRunner }()
+	c, configDir := newTestController(t, runner)
+	c.handlers["HostsConfiguration"] = &fileHandler{}
+	testutil.WriteFile(t, c.fs, driftFile, "edited by hand\n")
+	writeResource(t, configDir, "drift.json", `{"kind": "HostsConfiguration", "metadata": {"name": "drift"}, "spec": {"hosts": []}}`)
+	writeResource(t, configDir, "time.json", timeResource)
+
+	plans, err := c.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("got %d plans, want 2", len(plans))
+	}
+	plan := plans[0]
+	if plan.Err != nil || len(plan.Changes) != 1 || plan.Changes[0].Path != driftFile {
+		t.Errorf("got plan %+v, want a change of %s", plan, driftFile)
+	}
+	if want := [][]string{{"systemctl", "reload", "drift"}}; !slices.EqualFunc(plan.Commands, want, slices.Equal) {
+		t.Errorf("got planned commands %q, want %q", plan.Commands, want)
+	}
+	if plans[1].Note == "" {
+		t.Errorf("got plan %+v, want the handler without planning skipped", plans[1])
+	}
+
+	// 预览不执行命令，不修改文件，也不保存状态
+	if got := runner.Commands(); len(got) != 0 {
+		t.Errorf("plan ran commands: %q", got)
+	}
+	if current, _ := c.fs.ReadFile(driftFile); string(current) != "edited by hand\n" {
+		t.Errorf("plan changed %s:\n%s", driftFile, current)
+	}
+	if _, err := c.fs.Stat(utils.BackupPath(driftFile)); !os.IsNotExist(err) {
+		t.Errorf("plan backed up %s: %v", driftFile, err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, StatusDirName)); !os.IsNotExist(err) {
+		t.Errorf("plan saved status: %v", err)
+	}
+}
+
+func TestPlanDoesNotMigrateStatus(t *testing.T) {
+	root := testutil.NewRoot(t)
+	testutil.WriteFile(t, root, "/etc/os-release", "ID=debian\nVERSION_ID=\"12\"\n")
+	configDir := t.TempDir()
+	statusDir := filepath.Join(configDir, StatusDirName)
+	if err := os.Mkdir(statusDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := filepath.Join(statusDir, "time.json")
+	content := `{"kind": "TimeConfiguration", "name": "time", "path": "` + filepath.Join(configDir, "time.json") + `"}`
+	if err := os.WriteFile(legacy, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeResource(t, configDir, "time.json", timeResource)
+
+	c, err := NewController(configDir, WithRoot(root.Root()), WithRunner(&testutil.FakeRunner{}), WithReadOnlyStatus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Plan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本的状态文件保持原样，也不创建按类型划分的目录
+	if data, err := os.ReadFile(legacy); err != nil || string(data) != content {
+		t.Errorf("legacy status changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(statusDir, "TimeConfiguration")); !os.IsNotExist(err) {
+		t.Errorf("plan migrated status: %v", err)
+	}
+	if err := c.StatusStore().Save(&ResourceState{Kind: "TimeConfiguration", Name: "time"}); err == nil {
+		t.Error("expected saving to a read-only status store to fail")
+	}
+}
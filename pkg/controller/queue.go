@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 计算失败重试前的等待时间
+type RateLimiter interface {
+	// When 记录一次失败并返回下次重试前的等待时间
+	When(key string) time.Duration
+	// Forget 清除失败记录
+	Forget(key string)
+	// NumRequeues 返回连续失败的次数
+	NumRequeues(key string) int
+}
+
+// ExponentialBackoff 按 Base*2^n 计算等待时间，最长不超过 Max
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func NewExponentialBackoff(base, max time.Duration) *ExponentialBackoff {
+	return &ExponentialBackoff{
+		Base:     base,
+		Max:      max,
+		failures: make(map[string]int),
+	}
+}
+
+func (b *ExponentialBackoff) When(key string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	exp := b.failures[key]
+	b.failures[key]++
+
+	delay := b.Base
+	for i := 0; i < exp && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+func (b *ExponentialBackoff) Forget(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key)
+}
+
+func (b *ExponentialBackoff) NumRequeues(key string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures[key]
+}
+
+// WorkQueue 是支持延迟入队和失败限速的去重工作队列
+// 同一个 key 在队列中最多出现一次；处理中的 key 再次入队时，会在 Done 之后重新排队
+type WorkQueue struct {
+	limiter RateLimiter
+
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []string
+	dirty      map[string]struct{}
+	processing map[string]struct{}
+	waiting    map[string]*delayedItem
+	shutdown   bool
+}
+
+type delayedItem struct {
+	timer *time.Timer
+	at    time.Time
+}
+
+func NewWorkQueue(limiter RateLimiter) *WorkQueue {
+	q := &WorkQueue{
+		limiter:    limiter,
+		dirty:      make(map[string]struct{}),
+		processing: make(map[string]struct{}),
+		waiting:    make(map[string]*delayedItem),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Add 立即将 key 加入队列
+func (q *WorkQueue) Add(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.add(key)
+}
+
+func (q *WorkQueue) add(key string) {
+	if q.shutdown {
+		return
+	}
+	if item, ok := q.waiting[key]; ok {
+		item.timer.Stop()
+		delete(q.waiting, key)
+	}
+	if _, ok := q.dirty[key]; ok {
+		return
+	}
+	q.dirty[key] = struct{}{}
+	if _, ok := q.processing[key]; ok {
+		return
+	}
+	q.queue = append(q.queue, key)
+	q.cond.Signal()
+}
+
+// AddAfter 在 delay 之后将 key 加入队列，已有更早的延迟入队时保留较早的一个
+func (q *WorkQueue) AddAfter(key string, delay time.Duration) {
+	if delay <= 0 {
+		q.Add(key)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shutdown {
+		return
+	}
+
+	at := time.Now().Add(delay)
+	if item, ok := q.waiting[key]; ok {
+		if !item.at.After(at) {
+			return
+		}
+		item.timer.Stop()
+	}
+
+	item := &delayedItem{at: at}
+	item.timer = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.waiting[key] != item {
+			return
+		}
+		delete(q.waiting, key)
+		q.add(key)
+	})
+	q.waiting[key] = item
+}
+
+// AddRateLimited 按限速器给出的等待时间重新入队
+func (q *WorkQueue) AddRateLimited(key string) time.Duration {
+	delay := q.limiter.When(key)
+	q.AddAfter(key, delay)
+	return delay
+}
+
+// Forget 清除 key 的失败记录
+func (q *WorkQueue) Forget(key string) {
+	q.limiter.Forget(key)
+}
+
+// NumRequeues 返回 key 连续失败重试的次数
+func (q *WorkQueue) NumRequeues(key string) int {
+	return q.limiter.NumRequeues(key)
+}
+
+// Get 阻塞直到有可处理的 key，队列关闭时 shutdown 为 true
+func (q *WorkQueue) Get() (key string, shutdown bool) {
+	return q.GetTimeout(0)
+}
+
+// GetTimeout 与 Get 相同，但最多等待 timeout，超时时返回空的 key；timeout 为 0 时不超时
+func (q *WorkQueue) GetTimeout(timeout time.Duration) (key string, shutdown bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	expired := false
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			expired = true
+			q.cond.Broadcast()
+		})
+		defer timer.Stop()
+	}
+
+	for len(q.queue) == 0 && !q.shutdown && !expired {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		return "", q.shutdown
+	}
+
+	key = q.queue[0]
+	q.queue = q.queue[1:]
+	q.processing[key] = struct{}{}
+	delete(q.dirty, key)
+	return key, false
+}
+
+// Done 标记 key 处理完成
+func (q *WorkQueue) Done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.processing, key)
+	if _, ok := q.dirty[key]; ok {
+		q.queue = append(q.queue, key)
+		q.cond.Signal()
+	}
+}
+
+// Len 返回等待处理的 key 数量
+func (q *WorkQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+// ShutDown 关闭队列，丢弃尚未到期的延迟入队
+func (q *WorkQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.shutdown = true
+	for key, item := range q.waiting {
+		item.timer.Stop()
+		delete(q.waiting, key)
+	}
+	q.cond.Broadcast()
+}
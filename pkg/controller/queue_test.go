@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+// get 在超时前从队列中取出一个 key
+func get(t *testing.T, q *WorkQueue) string {
+	t.Helper()
+	got := make(chan string, 1)
+	go func() {
+		key, _ := q.Get()
+		got <- key
+	}()
+	select {
+	case key := <-got:
+		return key
+	case <-time.After(5 * time.Second):
+		t.Fatal("no key queued")
+		return ""
+	}
+}
+
+func TestWorkQueueDedupe(t *testing.T) {
+	q := NewWorkQueue(NewExponentialBackoff(time.Millisecond, time.Second))
+	q.Add("a")
+	q.Add("a")
+	if n := q.Len(); n != 1 {
+		t.Fatalf("got %d queued keys, want 1", n)
+	}
+
+	// 处理中的 key 再次入队时在 Done 之后重新排队，且只排队一次
+	if key := get(t, q); key != "a" {
+		t.Fatalf("got %s, want a", key)
+	}
+	q.Add("a")
+	q.Add("a")
+	if n := q.Len(); n != 0 {
+		t.Fatalf("got %d queued keys while a is processing, want 0", n)
+	}
+	q.Done("a")
+	if n := q.Len(); n != 1 {
+		t.Fatalf("got %d queued keys after Done, want 1", n)
+	}
+	if key := get(t, q); key != "a" {
+		t.Fatalf("got %s, want a", key)
+	}
+	q.Done("a")
+	if n := q.Len(); n != 0 {
+		t.Errorf("got %d queued keys, want 0", n)
+	}
+}
+
+func TestWorkQueueAddAfter(t *testing.T) {
+	q := NewWorkQueue(NewExponentialBackoff(time.Millisecond, time.Second))
+	q.AddAfter("a", 200*time.Millisecond)
+	q.AddAfter("b", 50*time.Millisecond)
+	// 已有更早的延迟入队时保留较早的一个
+	q.AddAfter("b", time.Hour)
+	if n := q.Len(); n != 0 {
+		t.Fatalf("got %d queued keys before the delay, want 0", n)
+	}
+
+	if key := get(t, q); key != "b" {
+		t.Errorf("got %s first, want b", key)
+	}
+	if key := get(t, q); key != "a" {
+		t.Errorf("got %s second, want a", key)
+	}
+
+	// 立即入队取消尚未到期的延迟入队
+	q.AddAfter("c", 50*time.Millisecond)
+	q.Add("c")
+	if key := get(t, q); key != "c" {
+		t.Fatalf("got %s, want c", key)
+	}
+	q.Done("c")
+	time.Sleep(100 * time.Millisecond)
+	if n := q.Len(); n != 0 {
+		t.Errorf("got %d queued keys, want the delayed c dropped", n)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff(time.Second, 5*time.Second)
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range want {
+		if got := b.When("a"); got != delay {
+			t.Errorf("failure %d: got %s, want %s", i+1, got, delay)
+		}
+	}
+	if n := b.NumRequeues("a"); n != len(want) {
+		t.Errorf("got %d requeues, want %d", n, len(want))
+	}
+	if got := b.When("b"); got != time.Second {
+		t.Errorf("got %s for another key, want %s", got, time.Second)
+	}
+
+	// Forget 后从 Base 重新计算
+	b.Forget("a")
+	if n := b.NumRequeues("a"); n != 0 {
+		t.Errorf("got %d requeues after Forget, want 0", n)
+	}
+	if got := b.When("a"); got != time.Second {
+		t.Errorf("got %s after Forget, want %s", got, time.Second)
+	}
+}
+
+func TestWorkQueueGetTimeout(t *testing.T) {
+	q := NewWorkQueue(NewExponentialBackoff(time.Millisecond, time.Second))
+
+	start := time.Now()
+	if key, shutdown := q.GetTimeout(20 * time.Millisecond); key != "" || shutdown {
+		t.Fatalf("got key %q, shutdown %v on an empty queue", key, shutdown)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("returned after %v, want at least the timeout", elapsed)
+	}
+
+	q.Add("a")
+	if key, _ := q.GetTimeout(time.Second); key != "a" {
+		t.Errorf("got key %q, want a", key)
+	}
+	q.Done("a")
+
+	q.ShutDown()
+	if _, shutdown := q.GetTimeout(time.Second); !shutdown {
+		t.Error("expected shutdown")
+	}
+}
+
+func TestWorkQueueShutDown(t *testing.T) {
+	q := NewWorkQueue(NewExponentialBackoff(time.Millisecond, time.Second))
+	q.Add("a")
+	q.Add("b")
+	q.AddAfter("c", 50*time.Millisecond)
+	q.ShutDown()
+
+	// 关闭前已入队的 key 仍可取出，之后的入队和尚未到期的延迟入队被丢弃
+	q.Add("d")
+	for _, want := range []string{"a", "b"} {
+		key, shutdown := q.Get()
+		if shutdown || key != want {
+			t.Fatalf("got %s shutdown=%v, want %s", key, shutdown, want)
+		}
+		q.Done(key)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if key, shutdown := q.Get(); !shutdown {
+		t.Errorf("got %s, want shutdown", key)
+	}
+
+	// 关闭队列唤醒等待中的 Get
+	q = NewWorkQueue(NewExponentialBackoff(time.Millisecond, time.Second))
+	done := make(chan bool, 1)
+	go func() {
+		_, shutdown := q.Get()
+		done <- shutdown
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.ShutDown()
+	select {
+	case shutdown := <-done:
+		if !shutdown {
+			t.Error("expected shutdown")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get not woken up by ShutDown")
+	}
+}
+
+func TestProcessNextItemGivesUpAfterMaxRetries(t *testing.T) {
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"chronyc reload sources": {Output: "501 Not authorised", ExitCode: 1},
+	}}
+	c, configDir := newTestController(t, runner)
+	c.maxRetries = 2
+	c.queue = NewWorkQueue(NewExponentialBackoff(time.Millisecond, 4*time.Millisecond))
+	path := writeResource(t, configDir, "time.json", timeResource)
+
+	// 首次调谐和两次重试
+	c.queue.Add(path)
+	for i := 0; i < 3; i++ {
+		if !c.processNextItem(c.handlerContext()) {
+			t.Fatal("queue shut down")
+		}
+	}
+	attempts := 0
+	for _, command := range runner.Commands() {
+		if strings.HasPrefix(command, "chronyc") {
+			attempts++
+		}
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+
+	// 放弃后清除失败记录，不再重新入队
+	if n := c.queue.NumRequeues(path); n != 0 {
+		t.Errorf("got %d requeues after giving up, want 0", n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := c.queue.Len(); n != 0 {
+		t.Errorf("got %d queued paths after giving up, want 0", n)
+	}
+
+	// 修改配置文件后重新开始计数
+	if err := os.WriteFile(path, []byte(timeResource+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c.queue.Add(path)
+	if !c.processNextItem(c.handlerContext()) {
+		t.Fatal("queue shut down")
+	}
+	if n := c.queue.NumRequeues(path); n != 1 {
+		t.Errorf("got %d requeues, want 1", n)
+	}
+}
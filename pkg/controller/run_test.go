@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// blockingRunner 在 release 关闭或 ctx 结束前阻塞每一条命令
+type blockingRunner struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingRunner() *blockingRunner {
+	return &blockingRunner{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (r *blockingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.started <- struct{}{}
+	select {
+	case <-r.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startRun 在后台运行控制器，等待第一条命令开始执行后返回
+func startRun(t *testing.T, c *Controller, runner *blockingRunner) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Run(ctx)
+	}()
+	select {
+	case <-runner.started:
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("reconcile did not start")
+	}
+	return cancel, done
+}
+
+func waitRun(t *testing.T, done <-chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestRunDrainsInFlightReconcile(t *testing.T) {
+	runner := newBlockingRunner()
+	c, configDir := newTestController(t, runner)
+	writeResource(t, configDir, "time.json", timeResource)
+
+	cancel, done := startRun(t, c, runner)
+	cancel()
+	// 退出信号不会中断进行中的调谐
+	time.Sleep(50 * time.Millisecond)
+	close(runner.release)
+	waitRun(t, done)
+
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseReady {
+		t.Errorf("got phase %s, want %s", state.Status.Phase, config.PhaseReady)
+	}
+
+	// 退出后监听立即结束
+	events, err := c.Watch(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range events {
+	}
+}
+
+func TestRunCancelsAfterDrainTimeout(t *testing.T) {
+	runner := newBlockingRunner()
+	c, configDir := newTestController(t, runner)
+	c.drainTimeout = 50 * time.Millisecond
+	writeResource(t, configDir, "time.json", timeResource)
+
+	cancel, done := startRun(t, c, runner)
+	cancel()
+	waitRun(t, done)
+
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseFailed || !strings.Contains(state.Status.Message, "context canceled") {
+		t.Errorf("got status %+v, want the reconcile cancelled", state.Status)
+	}
+}
+
+func TestReconcileTimeout(t *testing.T) {
+	runner := newBlockingRunner()
+	c, configDir := newTestController(t, runner)
+	c.timeout = 50 * time.Millisecond
+	path := writeResource(t, configDir, "time.json", timeResource)
+
+	// 处理器把被终止的命令当作服务未运行，调谐仍被标记为失败以便重试
+	if _, err := c.syncPath(c.handlerContext(), path); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	state, err := c.StatusStore().Load("TimeConfiguration", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status.Phase != config.PhaseFailed || !strings.Contains(state.Status.Message, "deadline exceeded") {
+		t.Errorf("got status %+v, want the reconcile timed out", state.Status)
+	}
+}
+
+func TestRunNotifiesSystemd(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	c.notifier = utils.NewNotifier(socket)
+	c.watchdog = 100 * time.Millisecond
+	writeResource(t, configDir, "time.json", timeResource)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Run(ctx)
+	}()
+
+	// 等待就绪、资源状态汇总和看门狗通知
+	want := map[string]bool{"READY=1": false, "STATUS=1 resources: 1 Ready": false, "WATCHDOG=1": false}
+	received := func() bool {
+		for _, ok := range want {
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	buf := make([]byte, 1024)
+	for !received() {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("got notifications %v: %v", want, err)
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if _, ok := want[line]; ok {
+				want[line] = true
+			}
+		}
+	}
+
+	cancel()
+	waitRun(t, done)
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal("STOPPING=1 not received")
+		}
+		if strings.HasPrefix(string(buf[:n]), "STOPPING=1") {
+			break
+		}
+	}
+}
+
+func TestRunStopsWatchdogWhenLoopIsStuck(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// 未设置调谐超时时，卡住的调谐使工作循环停止推进
+	runner := newBlockingRunner()
+	c, configDir := newTestController(t, runner)
+	c.notifier = utils.NewNotifier(socket)
+	c.watchdog = 100 * time.Millisecond
+	c.timeout = 0
+	c.drainTimeout = 50 * time.Millisecond
+	writeResource(t, configDir, "time.json", timeResource)
+
+	cancel, done := startRun(t, c, runner)
+	defer func() {
+		cancel()
+		waitRun(t, done)
+	}()
+
+	// 丢弃工作循环停止推进前发出的通知
+	time.Sleep(2 * c.watchdog)
+	buf := make([]byte, 1024)
+	for {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * c.watchdog))
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			break
+		}
+		if strings.Contains(string(buf[:n]), "WATCHDOG=1") {
+			t.Fatal("watchdog notified while the controller loop is stuck")
+		}
+	}
+}
+
+func TestWatchDebouncesEvents(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	c.debounce = 300 * time.Millisecond
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(configDir); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.watch(ctx, watcher)
+
+	// 连续的写入在最后一次事件后的 debounce 时间内合并
+	var path string
+	for i := 0; i < 3; i++ {
+		path = writeResource(t, configDir, "time.json", timeResource)
+		time.Sleep(50 * time.Millisecond)
+	}
+	other := writeResource(t, configDir, "time-b.json", timeResource)
+	if n := c.queue.Len(); n != 0 {
+		t.Fatalf("got %d queued paths before the debounce period ended", n)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.queue.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := map[string]bool{}
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		got[key] = true
+		c.queue.Done(key)
+	}
+	if len(got) != 2 || !got[path] || !got[other] {
+		t.Errorf("got queued paths %v, want %s and %s", got, path, other)
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+var specTypes = make(map[string]reflect.Type)
+
+// RegisterSpec 注册资源类型的 spec，spec 是指向零值的指针
+// 控制器在调谐前按 spec 严格解析并校验资源
+func RegisterSpec(kind string, spec validation.Spec) {
+	specTypes[kind] = reflect.TypeOf(spec).Elem()
+}
+
+// NewSpec 返回资源类型的 spec 实例，类型未注册 spec 时 ok 为 false
+func NewSpec(kind string) (spec validation.Spec, ok bool) {
+	t, ok := specTypes[kind]
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface().(validation.Spec), true
+}
+
+// SpecKinds 返回已注册 spec 的资源类型，按名称排序
+func SpecKinds() []string {
+	return slices.Sorted(maps.Keys(specTypes))
+}
+
+// ValidateResource 校验资源的元数据和 spec，未注册 spec 的类型只校验元数据
+func ValidateResource(cfg *config.ResourceConfig) validation.ErrorList {
+	var errs validation.ErrorList
+	namePath := validation.NewPath("metadata").Child("name")
+	switch {
+	case cfg.Metadata.Name == "":
+		errs = append(errs, validation.Required(namePath))
+	case !resourceName.MatchString(cfg.Metadata.Name):
+		errs = append(errs, validation.Invalid(namePath, cfg.Metadata.Name, "must consist of letters, digits, '-', '_' or '.' and start with a letter or digit"))
+	}
+	if spec, ok := NewSpec(cfg.Kind); ok {
+		errs = append(errs, validation.DecodeSpec(cfg.Spec, spec)...)
+	}
+	return errs
+}
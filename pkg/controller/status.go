@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+// StatusDirName 是配置目录下默认的状态目录名
+const StatusDirName = ".status"
+
+// ResourceState 是持久化到状态目录中的资源状态
+type ResourceState struct {
+	Kind      string                 `json:"kind"`
+	Name      string                 `json:"name"`
+	Path      string                 `json:"path"` // 资源所在的配置文件
+	Status    *config.ResourceStatus `json:"status"`
+	Effective *config.ResourceConfig `json:"effectiveConfig,omitempty"`
+	// Generation 是期望配置的代数，SpecHash 是该代数对应的 spec 摘要
+	Generation int    `json:"generation,omitempty"`
+	SpecHash   string `json:"specHash,omitempty"`
+}
+
+// StatusStore 以每个资源一个 JSON 文件的形式保存调谐状态
+type StatusStore struct {
+	dir      string
+	readOnly bool
+	mu       sync.RWMutex
+}
+
+// errReadOnly 表示只读的状态存储不能被修改
+var errReadOnly = errors.New("status store is read-only")
+
+// NewStatusStore 创建状态存储，状态目录在首次保存时创建
+func NewStatusStore(dir string) *StatusStore {
+	return &StatusStore{dir: dir}
+}
+
+// NewReadOnlyStatusStore 创建只读的状态存储，旧版本的状态文件不被迁移，修改时返回错误
+func NewReadOnlyStatusStore(dir string) *StatusStore {
+	return &StatusStore{dir: dir, readOnly: true}
+}
+
+// path 返回资源状态文件的路径，状态按类型分目录保存，不同类型的同名资源互不影响
+func (s *StatusStore) path(kind, name string) (string, error) {
+	if !resourceName.MatchString(kind) {
+		return "", fmt.Errorf("invalid resource kind %q", kind)
+	}
+	if !resourceName.MatchString(name) {
+		return "", fmt.Errorf("invalid resource name %q", name)
+	}
+	return filepath.Join(s.dir, kind, name+".json"), nil
+}
+
+// Save 原子性地写入资源状态
+func (s *StatusStore) Save(state *ResourceState) error {
+	path, err := s.path(state.Kind, state.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeState(path, data)
+}
+
+func (s *StatusStore) writeState(path string, data []byte) error {
+	if s.readOnly {
+		return errReadOnly
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create status directory: %v", err)
+	}
+	return utils.AtomicWriteFile(data, path, 0644)
+}
+
+// Update 读取并修改资源状态，期间不会被其他保存覆盖；update 返回 false 时不写回
+func (s *StatusStore) Update(kind, name string, update func(state *ResourceState) bool) error {
+	path, err := s.path(kind, name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := readState(path)
+	if err != nil {
+		return err
+	}
+	if !update(state) {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %v", err)
+	}
+	return s.writeState(path, data)
+}
+
+// Load 读取资源状态，资源不存在时返回 os.ErrNotExist
+func (s *StatusStore) Load(kind, name string) (*ResourceState, error) {
+	path, err := s.path(kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return readState(path)
+}
+
+func readState(path string) (*ResourceState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state ResourceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal status %s: %v", path, err)
+	}
+	return &state, nil
+}
+
+// Delete 删除资源状态，状态不存在时不报错
+func (s *StatusStore) Delete(kind, name string) error {
+	path, err := s.path(kind, name)
+	if err != nil {
+		return err
+	}
+
+	if s.readOnly {
+		return errReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List 返回所有已保存的资源状态
+// 旧版本以 <name>.json 保存在状态目录下的状态被迁移到对应类型的目录中
+func (s *StatusStore) List() ([]*ResourceState, error) {
+	s.mu.RLock()
+	entries, err := os.ReadDir(s.dir)
+	s.mu.RUnlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var states []*ResourceState
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if state := s.migrate(entry.Name()); state != nil {
+				states = append(states, state)
+			}
+			continue
+		}
+
+		s.mu.RLock()
+		files, err := os.ReadDir(filepath.Join(s.dir, entry.Name()))
+		s.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+				continue
+			}
+			state, err := s.Load(entry.Name(), strings.TrimSuffix(file.Name(), ".json"))
+			if err != nil {
+				continue
+			}
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// migrate 将旧版本的状态文件移动到按类型划分的目录中，返回迁移后的状态
+// 只读的状态存储只读取旧版本的状态文件
+func (s *StatusStore) migrate(file string) *ResourceState {
+	if filepath.Ext(file) != ".json" {
+		return nil
+	}
+	legacy := filepath.Join(s.dir, file)
+	state, err := readState(legacy)
+	if err != nil || s.readOnly {
+		return state
+	}
+	if err := s.Save(state); err != nil {
+		slog.Warn("Failed to migrate status", "path", legacy, "error", err)
+		return nil
+	}
+	if err := os.Remove(legacy); err != nil {
+		slog.Warn("Failed to remove migrated status", "path", legacy, "error", err)
+	}
+	return state
+}
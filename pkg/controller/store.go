@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/utils"
+	"go.xbrother.com/nix-operator/pkg/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotFound 表示配置目录中不存在指定名称的资源
+var ErrNotFound = errors.New("resource not found")
+
+// ErrAlreadyExists 表示创建资源时 <name>.json 已存在但没有声明该资源
+var ErrAlreadyExists = errors.New("config file already exists")
+
+// resourceName 匹配可以作为文件名的资源名称和类型，新资源保存为 <name>.json，状态保存为 <kind>/<name>.json
+var resourceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Resource 是配置目录中的资源及其最近一次调谐的结果
+type Resource struct {
+	Path      string                 // 资源所在的配置文件
+	Config    *config.ResourceConfig // 配置文件中的期望配置
+	Effective *config.ResourceConfig // 最近一次成功调谐的配置，尚未调谐时为 nil
+	Status    *config.ResourceStatus // 最近一次调谐的状态，尚未调谐时为 nil
+}
+
+// ListResources 返回配置目录中的资源，kind 不为空时只返回该类型的资源
+// 无法解析的配置文件被跳过
+func (c *Controller) ListResources(kind string) ([]*Resource, error) {
+	files, err := c.configFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []*Resource
+	for _, path := range files {
+		cfgs, err := readConfigFile(path)
+		if err != nil {
+			slog.Error("Failed to load config file", "path", path, "error", err)
+			continue
+		}
+		for _, cfg := range cfgs {
+			if kind == "" || cfg.Kind == kind {
+				resources = append(resources, c.resource(path, cfg))
+			}
+		}
+	}
+	return resources, nil
+}
+
+// GetResource 返回指定类型和名称的资源，资源不存在时返回 ErrNotFound
+// kind 为空时匹配任意类型，此时存在多个同名资源则返回校验错误
+func (c *Controller) GetResource(kind, name string) (*Resource, error) {
+	resources, err := c.findResources(name)
+	if err != nil {
+		return nil, err
+	}
+	var found *Resource
+	for _, resource := range resources {
+		if kind != "" && resource.Config.Kind != kind {
+			continue
+		}
+		if found != nil {
+			return nil, validation.ErrorList{validation.Invalid(validation.NewPath("kind"), kind, "required, there are resources of different kinds named "+name)}
+		}
+		found = resource
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// findResources 返回配置目录中所有指定名称的资源，重复声明的资源只返回最先声明的一个
+func (c *Controller) findResources(name string) ([]*Resource, error) {
+	resources, err := c.ListResources("")
+	if err != nil {
+		return nil, err
+	}
+	var found []*Resource
+	for _, resource := range resources {
+		if resource.Config.Metadata.Name == name && c.duplicateOwner(resource.Path, resource.Config) == "" {
+			found = append(found, resource)
+		}
+	}
+	return found, nil
+}
+
+// UpdateResource 校验并原子性地写入资源配置，资源不存在时创建 <config-dir>/<name>.json
+// 写入的文件由文件监听触发调谐，返回的状态是写入前最近一次调谐的状态
+// cfg 的 resourceVersion 不为空时只在与当前版本一致时更新，否则返回 ErrConflict
+// spec 变化时代数加一；校验失败时返回 validation.ErrorList，新资源的文件已存在时返回 ErrAlreadyExists
+func (c *Controller) UpdateResource(name string, cfg *config.ResourceConfig) (*Resource, error) {
+	namePath := validation.NewPath("metadata").Child("name")
+	switch {
+	case cfg.Metadata.Name == "":
+		cfg.Metadata.Name = name
+	case cfg.Metadata.Name != name:
+		return nil, validation.ErrorList{validation.Invalid(namePath, cfg.Metadata.Name, "must match the resource name "+name)}
+	}
+	if cfg.Kind == "" {
+		return nil, validation.ErrorList{validation.Required(validation.NewPath("kind"))}
+	}
+	if errs := ValidateResource(cfg); len(errs) > 0 {
+		return nil, errs
+	}
+
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+
+	// API 中资源只以名称区分，不能创建与其他类型资源同名的资源
+	resources, err := c.findResources(name)
+	if err != nil {
+		return nil, err
+	}
+	var current *Resource
+	for _, resource := range resources {
+		if resource.Config.Kind == cfg.Kind {
+			current = resource
+		}
+	}
+	if current == nil && len(resources) > 0 {
+		return nil, validation.ErrorList{validation.Invalid(validation.NewPath("kind"), cfg.Kind, "cannot change the kind of "+resources[0].Config.Kind+" resource")}
+	}
+
+	if version := cfg.Metadata.ResourceVersion; version != "" {
+		switch {
+		case current == nil:
+			return nil, fmt.Errorf("%w: resource %s does not exist", ErrConflict, name)
+		case current.Config.Metadata.ResourceVersion != version:
+			return nil, fmt.Errorf("%w: resource %s has been modified, current version is %s",
+				ErrConflict, name, current.Config.Metadata.ResourceVersion)
+		}
+	}
+	// 版本由内容计算，不写入配置文件
+	cfg.Metadata.ResourceVersion = ""
+
+	path := filepath.Join(c.configDir, name+".json")
+	if current != nil {
+		path = current.Path
+		cfg.Metadata.CreationTime = current.Config.Metadata.CreationTime
+		cfg.Metadata.Generation = current.Config.Metadata.Generation
+		if specHash(cfg.Spec) != specHash(current.Config.Spec) {
+			cfg.Metadata.Generation++
+		}
+	} else {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%w: %s does not declare %s %s", ErrAlreadyExists, path, cfg.Kind, name)
+		}
+		cfg.Metadata.CreationTime = time.Now().UTC().Format(time.RFC3339)
+		cfg.Metadata.Generation = 1
+	}
+
+	if err := writeConfigFile(path, cfg); err != nil {
+		return nil, err
+	}
+	slog.Info("Updated resource", "kind", cfg.Kind, "name", name, "path", path, "generation", cfg.Metadata.Generation)
+	return c.resource(path, cfg), nil
+}
+
+// resource 设置配置的代数和版本，并附加状态存储中的生效配置和状态
+// 与其他资源冲突而不被调谐的资源状态为 DuplicateResource
+func (c *Controller) resource(path string, cfg *config.ResourceConfig) *Resource {
+	resource := &Resource{Path: path, Config: cfg}
+	if message := c.conflict(path, cfg); message != "" {
+		resolveGeneration(cfg, nil)
+		cfg.Metadata.ResourceVersion = resourceVersion(cfg)
+		resource.Status = &config.ResourceStatus{
+			Phase:   config.PhaseFailed,
+			Reason:  "DuplicateResource",
+			Message: message,
+		}
+		return resource
+	}
+	state, err := c.status.Load(cfg.Kind, cfg.Metadata.Name)
+	if err != nil {
+		state = nil
+	}
+	resolveGeneration(cfg, state)
+	cfg.Metadata.ResourceVersion = resourceVersion(cfg)
+	if state != nil {
+		resource.Effective = state.Effective
+		resource.Status = state.Status
+	}
+	return resource
+}
+
+// configFiles 返回配置目录中的所有配置文件，跳过隐藏文件和目录
+func (c *Controller) configFiles() ([]string, error) {
+	var files []string
+	err := filepath.Walk(c.configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if isHidden(path, c.configDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if c.isConfigFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func readConfigFile(path string) ([]*config.ResourceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	return loadConfigFile(path, data)
+}
+
+// writeConfigFile 将资源写入配置文件，YAML 文件中的其他文档保持不变
+func writeConfigFile(path string, cfg *config.ResourceConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource: %v", err)
+	}
+	if filepath.Ext(path) != ".json" {
+		if data, err = replaceYAMLResource(path, cfg, data); err != nil {
+			return err
+		}
+	} else {
+		data = append(data, '\n')
+	}
+	return utils.AtomicWriteFile(data, path, 0644)
+}
+
+// replaceYAMLResource 用 cfg 替换 YAML 文件中同类型同名的文档，保留其他文档及其注释
+func replaceYAMLResource(path string, cfg *config.ResourceConfig, data []byte) ([]byte, error) {
+	// JSON 也是合法的 YAML，解析为节点后以块格式输出，字段顺序不变
+	var replacement yaml.Node
+	if err := yaml.Unmarshal(data, &replacement); err != nil {
+		return nil, fmt.Errorf("failed to convert resource to YAML: %v", err)
+	}
+	blockStyle(&replacement)
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var (
+		docs     []*yaml.Node
+		replaced bool
+	)
+	decoder := yaml.NewDecoder(bytes.NewReader(current))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, yamlError(path, err)
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+		if existing, err := decodeYAMLResource(path, doc.Content[0]); err == nil &&
+			existing.Kind == cfg.Kind && existing.Metadata.Name == cfg.Metadata.Name {
+			doc = &replacement
+			replaced = true
+		}
+		docs = append(docs, doc)
+	}
+	if !replaced {
+		docs = append(docs, &replacement)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %v", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle 清除节点树的流格式和引号格式，使 JSON 转换来的节点以 YAML 块格式输出
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+func TestListAndGetResources(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	path := writeResource(t, configDir, "time.json", timeResource)
+	writeResource(t, configDir, "hosts.yaml", "kind: HostsConfiguration\nmetadata:\n  name: hosts\nspec:\n  hosts: []\n")
+	writeResource(t, configDir, "broken.json", "{")
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	resources, err := c.ListResources("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("got %d resources, want 2", len(resources))
+	}
+
+	resources, err = c.ListResources("TimeConfiguration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 1 || resources[0].Path != path {
+		t.Fatalf("got resources %+v", resources)
+	}
+
+	resource, err := c.GetResource("", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Status == nil || resource.Status.Phase != config.PhaseReady || resource.Effective == nil {
+		t.Errorf("got resource without reconcile result: %+v", resource)
+	}
+	if resource, err := c.GetResource("HostsConfiguration", "hosts"); err != nil || resource.Status != nil {
+		t.Errorf("got resource %+v, err %v, want no status before reconcile", resource, err)
+	}
+	if _, err := c.GetResource("", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	// 不同类型的同名资源需要指定类型
+	writeResource(t, configDir, "time-hosts.yaml", "kind: HostsConfiguration\nmetadata:\n  name: time\nspec:\n  hosts: []\n")
+	var errs validation.ErrorList
+	if _, err := c.GetResource("", "time"); !errors.As(err, &errs) || errs[0].Field != "kind" {
+		t.Errorf("got %v, want error for kind", err)
+	}
+	if resource, err := c.GetResource("HostsConfiguration", "time"); err != nil || resource.Config.Kind != "HostsConfiguration" {
+		t.Errorf("got resource %+v, err %v", resource, err)
+	}
+}
+
+func TestUpdateResource(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	writeResource(t, configDir, "time.json", timeResource)
+
+	// 更新已有资源时写回其所在的文件
+	cfg := &config.ResourceConfig{Kind: "TimeConfiguration", Spec: json.RawMessage(`{"timezone":"Asia/Shanghai"}`)}
+	if _, err := c.UpdateResource("time", cfg); err != nil {
+		t.Fatal(err)
+	}
+	resource, err := c.GetResource("", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Path != filepath.Join(configDir, "time.json") || !strings.Contains(string(resource.Config.Spec), "Asia/Shanghai") {
+		t.Errorf("got resource %s with spec %s", resource.Path, resource.Config.Spec)
+	}
+
+	// 新资源保存为 <name>.json
+	cfg = &config.ResourceConfig{Kind: "HostsConfiguration", Spec: json.RawMessage(`{"hosts":[]}`)}
+	if _, err := c.UpdateResource("hosts", cfg); err != nil {
+		t.Fatal(err)
+	}
+	resource, err = c.GetResource("HostsConfiguration", "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Path != filepath.Join(configDir, "hosts.json") || resource.Config.Metadata.CreationTime == "" {
+		t.Errorf("got new resource %+v", resource)
+	}
+
+	// 不覆盖声明其他资源的同名文件
+	writeResource(t, configDir, "udev.json", `{"kind": "UdevConfiguration", "metadata": {"name": "rules"}, "spec": {"rules": []}}`)
+	cfg = &config.ResourceConfig{Kind: "UdevConfiguration", Spec: json.RawMessage(`{"rules":[]}`)}
+	if _, err := c.UpdateResource("udev", cfg); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("got %v, want ErrAlreadyExists", err)
+	}
+}
+
+func TestUpdateResourceInvalid(t *testing.T) {
+	RegisterSpec("TimeConfiguration", &timeSpec{})
+	defer delete(specTypes, "TimeConfiguration")
+
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	writeResource(t, configDir, "time.json", timeResource)
+
+	tests := []struct {
+		name  string
+		cfg   *config.ResourceConfig
+		field string
+	}{
+		{"invalid spec", &config.ResourceConfig{Kind: "TimeConfiguration", Spec: json.RawMessage(`{"timezone":"Mars/Olympus"}`)}, "spec.timezone"},
+		{"name mismatch", &config.ResourceConfig{Kind: "TimeConfiguration", Metadata: config.Metadata{Name: "other"}, Spec: json.RawMessage(`{}`)}, "metadata.name"},
+		{"kind changed", &config.ResourceConfig{Kind: "HostsConfiguration", Spec: json.RawMessage(`{}`)}, "kind"},
+		{"missing kind", &config.ResourceConfig{Spec: json.RawMessage(`{}`)}, "kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.UpdateResource("time", tt.cfg)
+			var errs validation.ErrorList
+			if !errors.As(err, &errs) || errs[0].Field != tt.field {
+				t.Errorf("got %v, want error for %s", err, tt.field)
+			}
+		})
+	}
+
+	var errs validation.ErrorList
+	if _, err := c.UpdateResource("../etc", &config.ResourceConfig{Kind: "TimeConfiguration", Spec: json.RawMessage(`{}`)}); !errors.As(err, &errs) {
+		t.Errorf("got %v, want invalid name", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "time.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != timeResource {
+		t.Errorf("config file changed by rejected updates:\n%s", data)
+	}
+}
+
+func TestUpdateResourceYAML(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	path := writeResource(t, configDir, "time.yaml", `# 时间配置
+kind: TimeConfiguration
+metadata:
+  name: time-a
+spec: {}
+---
+# 保留的注释
+kind: TimeConfiguration
+metadata:
+  name: time-b
+spec: {}
+`)
+
+	cfg := &config.ResourceConfig{Kind: "TimeConfiguration", Spec: json.RawMessage(`{"timezone":"UTC","port":"8080"}`)}
+	if _, err := c.UpdateResource("time-a", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# 保留的注释") {
+		t.Errorf("comments of other documents were lost:\n%s", data)
+	}
+	cfgs, err := loadConfigFile(path, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) != 2 || cfgs[0].Metadata.Name != "time-a" || cfgs[1].Metadata.Name != "time-b" {
+		t.Fatalf("got resources %+v", cfgs)
+	}
+	// 字符串形式的数字保持为字符串
+	if got := string(cfgs[0].Spec); got != `{"port":"8080","timezone":"UTC"}` {
+		t.Errorf("got spec %s", got)
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+)
+
+// ErrConflict 表示更新基于的 resourceVersion 已过期
+var ErrConflict = errors.New("resource version conflict")
+
+// canonicalJSON 去掉 JSON 的格式差异并按键排序，无法解析时原样返回
+func canonicalJSON(raw json.RawMessage) []byte {
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return raw
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return raw
+	}
+	return data
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// specHash 返回 spec 的摘要，只有 spec 的取值变化时摘要才会变化
+func specHash(spec json.RawMessage) string {
+	return digest(canonicalJSON(spec))
+}
+
+// resolveGeneration 设置资源的代数
+// spec 相对状态中记录的 spec 变化时代数在记录的基础上加一，配置中声明的更大代数优先
+func resolveGeneration(cfg *config.ResourceConfig, state *ResourceState) {
+	generation := 1
+	if state != nil && state.Kind == cfg.Kind && state.SpecHash != "" {
+		generation = state.Generation
+		if state.SpecHash != specHash(cfg.Spec) {
+			generation++
+		}
+	}
+	if cfg.Metadata.Generation > generation {
+		generation = cfg.Metadata.Generation
+	}
+	cfg.Metadata.Generation = generation
+}
+
+// resourceVersion 返回资源内容的摘要，资源的任何字段变化都会改变它
+func resourceVersion(cfg *config.ResourceConfig) string {
+	c := *cfg
+	c.Metadata.ResourceVersion = ""
+	c.Spec = canonicalJSON(cfg.Spec)
+	data, err := json.Marshal(&c)
+	if err != nil {
+		return ""
+	}
+	return digest(data)
+}
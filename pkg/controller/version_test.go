@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+func TestGenerationOnFileChange(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	path := writeResource(t, configDir, "time.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`)
+
+	steps := []struct {
+		name       string
+		content    string
+		generation int
+	}{
+		{"created", "", 1},
+		{"format only", `{"kind": "TimeConfiguration", "metadata": {"name": "time", "labels": {"a": "b"}}, "spec": {  "timezone":"UTC" }}`, 1},
+		{"spec changed", `{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "Asia/Shanghai"}}`, 2},
+		{"declared generation", `{"kind": "TimeConfiguration", "metadata": {"name": "time", "generation": 5}, "spec": {"timezone": "Asia/Shanghai"}}`, 5},
+	}
+	for _, step := range steps {
+		if step.content != "" {
+			writeResource(t, configDir, "time.json", step.content)
+		}
+		if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+			t.Fatal(err)
+		}
+		state, err := c.StatusStore().Load("TimeConfiguration", "time")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Generation != step.generation || state.Status.ObservedGeneration != step.generation {
+			t.Errorf("%s: got generation %d observed %d, want %d",
+				step.name, state.Generation, state.Status.ObservedGeneration, step.generation)
+		}
+	}
+
+	// 尚未调谐的修改在读取时已体现为新的代数
+	writeResource(t, configDir, "time.json", `{"kind": "TimeConfiguration", "metadata": {"name": "time"}, "spec": {"timezone": "UTC"}}`)
+	resource, err := c.GetResource("", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.Config.Metadata.Generation != 6 || resource.Status.ObservedGeneration != 5 {
+		t.Errorf("got generation %d observed %d, want 6 and 5",
+			resource.Config.Metadata.Generation, resource.Status.ObservedGeneration)
+	}
+}
+
+func TestUpdateResourceConflict(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	writeResource(t, configDir, "time.json", timeResource)
+
+	current, err := c.GetResource("", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := current.Config.Metadata.ResourceVersion
+	if version == "" {
+		t.Fatal("resource version is empty")
+	}
+
+	// 只修改标签时代数不变
+	update := &config.ResourceConfig{
+		Kind:     "TimeConfiguration",
+		Metadata: config.Metadata{ResourceVersion: version, Labels: map[string]string{"site": "a"}},
+		Spec:     json.RawMessage(`{}`),
+	}
+	updated, err := c.UpdateResource("time", update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Config.Metadata.Generation != 1 || updated.Config.Metadata.ResourceVersion == version {
+		t.Errorf("got generation %d version %s after label update",
+			updated.Config.Metadata.Generation, updated.Config.Metadata.ResourceVersion)
+	}
+
+	// 基于旧版本的更新被拒绝
+	stale := &config.ResourceConfig{
+		Kind:     "TimeConfiguration",
+		Metadata: config.Metadata{ResourceVersion: version},
+		Spec:     json.RawMessage(`{"timezone": "UTC"}`),
+	}
+	if _, err := c.UpdateResource("time", stale); !errors.Is(err, ErrConflict) {
+		t.Fatalf("got %v, want ErrConflict", err)
+	}
+
+	stale.Metadata.ResourceVersion = updated.Config.Metadata.ResourceVersion
+	updated, err = c.UpdateResource("time", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Config.Metadata.Generation != 2 {
+		t.Errorf("got generation %d after spec update, want 2", updated.Config.Metadata.Generation)
+	}
+
+	got, err := c.GetResource("", "time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Config.Metadata.ResourceVersion != updated.Config.Metadata.ResourceVersion || got.Config.Metadata.Generation != 2 {
+		t.Errorf("got %s generation %d, want %s generation 2", got.Config.Metadata.ResourceVersion,
+			got.Config.Metadata.Generation, updated.Config.Metadata.ResourceVersion)
+	}
+
+	missing := &config.ResourceConfig{Kind: "TimeConfiguration", Metadata: config.Metadata{ResourceVersion: "1"}, Spec: json.RawMessage(`{}`)}
+	if _, err := c.UpdateResource("other", missing); !errors.Is(err, ErrConflict) {
+		t.Errorf("got %v, want ErrConflict for missing resource", err)
+	}
+}
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+)
+
+// 资源事件类型
+const (
+	EventAdded    = "ADDED"
+	EventModified = "MODIFIED"
+	EventDeleted  = "DELETED"
+)
+
+const (
+	// historySize 是保留的最近事件数，用于从指定序号恢复监听
+	historySize = 1024
+	// subscriberBuffer 是每个订阅者的事件缓冲，缓冲满时订阅被关闭
+	subscriberBuffer = 256
+)
+
+// ErrExpired 表示恢复监听的序号过旧，需要重新列出资源后再监听
+var ErrExpired = errors.New("watch sequence is too old")
+
+// Event 描述资源的配置或状态的变化
+type Event struct {
+	Type     string
+	Resource *Resource
+	Sequence uint64 // 事件的序号，进程内单调递增
+}
+
+// broadcaster 为资源的变化分配序号并分发给所有订阅者
+type broadcaster struct {
+	mu          sync.Mutex
+	sequence    uint64
+	seen        map[string]string // 资源类型和名称到最近一次事件内容的摘要
+	history     []Event
+	subscribers map[chan Event]struct{}
+	closed      bool
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{
+		seen:        make(map[string]string),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// seed 记录已有的资源，它们之后的变化以 MODIFIED 事件发出
+func (b *broadcaster) seed(resources []*Resource) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, resource := range resources {
+		b.seen[watchKey(resource)] = eventKey(resource)
+	}
+}
+
+// publish 发出资源的变化，新出现的资源为 ADDED 事件，内容与上次事件相同时忽略
+// eventType 为 EventDeleted 时表示资源已被删除，否则表示资源可能发生了变化
+func (b *broadcaster) publish(eventType string, resource *Resource) {
+	name := watchKey(resource)
+	key := eventKey(resource)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	last, ok := b.seen[name]
+	switch {
+	case eventType == EventDeleted:
+		if !ok {
+			return
+		}
+		delete(b.seen, name)
+	case !ok:
+		eventType = EventAdded
+		b.seen[name] = key
+	case last == key:
+		return
+	default:
+		eventType = EventModified
+		b.seen[name] = key
+	}
+
+	b.sequence++
+	event := Event{Type: eventType, Resource: resource, Sequence: b.sequence}
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// 订阅者处理过慢，关闭订阅由客户端从最后收到的序号恢复
+			slog.Warn("Closing slow watch subscriber", "sequence", event.Sequence)
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe 订阅之后的事件，返回序号在 since 之后的历史事件和当前的序号
+func (b *broadcaster) subscribe(since uint64) (chan Event, []Event, uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if since > 0 {
+		// 序号大于当前序号说明来自之前的进程
+		if since > b.sequence || (since < b.sequence && (len(b.history) == 0 || b.history[0].Sequence > since+1)) {
+			return nil, nil, 0, ErrExpired
+		}
+		for _, event := range b.history {
+			if event.Sequence > since {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	if b.closed {
+		close(ch)
+		return ch, replay, b.sequence, nil
+	}
+	b.subscribers[ch] = struct{}{}
+	return ch, replay, b.sequence, nil
+}
+
+func (b *broadcaster) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// close 在控制器退出时关闭所有订阅，之后的订阅在返回历史事件后立即结束
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// watchKey 返回区分资源的类型和名称
+func watchKey(resource *Resource) string {
+	return resourceKey(resource.Config)
+}
+
+// eventKey 返回资源中会被客户端关注的内容的摘要，忽略调谐时间和命令记录
+func eventKey(resource *Resource) string {
+	key := resourceVersion(resource.Config)
+	if resource.Status != nil {
+		status := *resource.Status
+		status.LastReconcileTime = ""
+		status.Commands = nil
+		data, err := json.Marshal(&status)
+		if err == nil {
+			key += digest(data)
+		}
+	}
+	return key
+}
+
+// Watch 监听资源的配置和状态变化，ctx 结束或订阅被关闭时关闭返回的 channel
+// since 为 0 时先以 ADDED 事件返回所有资源，否则从序号 since 之后的事件开始，
+// 序号过旧时返回 ErrExpired
+func (c *Controller) Watch(ctx context.Context, since uint64) (<-chan Event, error) {
+	ch, replay, sequence, err := c.events.subscribe(since)
+	if err != nil {
+		return nil, err
+	}
+	if since == 0 {
+		resources, err := c.ListResources("")
+		if err != nil {
+			c.events.unsubscribe(ch)
+			return nil, err
+		}
+		for _, resource := range resources {
+			replay = append(replay, Event{Type: EventAdded, Resource: resource, Sequence: sequence})
+		}
+	}
+
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		defer c.events.unsubscribe(ch)
+
+		for _, event := range replay {
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for {
+			select {
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// publish 在资源的配置或状态可能变化后通知订阅者
+func (c *Controller) publish(path string, cfg *config.ResourceConfig) {
+	if cfg.Metadata.Name == "" {
+		return
+	}
+	copied := *cfg
+	c.events.publish(EventModified, c.resource(path, &copied))
+}
+
+// publishDeleted 在资源被删除后通知订阅者
+func (c *Controller) publishDeleted(path string, cfg *config.ResourceConfig) {
+	if cfg.Metadata.Name == "" {
+		return
+	}
+	copied := *cfg
+	copied.Metadata.ResourceVersion = resourceVersion(&copied)
+	c.events.publish(EventDeleted, &Resource{Path: path, Config: &copied})
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+// drain 读取 channel 中已有的事件
+func drain(ch <-chan Event) []Event {
+	var events []Event
+	for {
+		select {
+		case event := <-ch:
+			events = append(events, event)
+		case <-time.After(100 * time.Millisecond):
+			return events
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := c.Watch(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeResource(t, configDir, "time.json", timeResource)
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	events := drain(ch)
+	if len(events) < 2 || events[0].Type != EventAdded {
+		t.Fatalf("got events %+v, want ADDED followed by status changes", events)
+	}
+	last := events[len(events)-1]
+	if last.Type != EventModified || last.Resource.Status.Phase != config.PhaseReady {
+		t.Errorf("got last event %s with status %+v", last.Type, last.Resource.Status)
+	}
+
+	// 内容没有变化的调谐不产生事件
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	if events := drain(ch); len(events) != 0 {
+		t.Errorf("got events %+v for unchanged resource", events)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.syncPath(c.handlerContext(), path); err != nil {
+		t.Fatal(err)
+	}
+	deleted := drain(ch)
+	if len(deleted) != 1 || deleted[0].Type != EventDeleted || deleted[0].Resource.Config.Metadata.Name != "time" {
+		t.Fatalf("got events %+v, want DELETED", deleted)
+	}
+
+	// 从指定序号恢复时重放之后的事件
+	resumed, err := c.Watch(ctx, events[0].Sequence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := drain(resumed)
+	if len(replay) != len(events) || replay[0].Sequence != events[1].Sequence {
+		t.Errorf("got %d replayed events starting at %d, want %d starting at %d",
+			len(replay), replay[0].Sequence, len(events), events[1].Sequence)
+	}
+
+	if _, err := c.Watch(ctx, deleted[0].Sequence+1); !errors.Is(err, ErrExpired) {
+		t.Errorf("got error %v for future sequence, want ErrExpired", err)
+	}
+}
+
+func TestWatchInitialList(t *testing.T) {
+	c, configDir := newTestController(t, &testutil.FakeRunner{})
+	writeResource(t, configDir, "time.json", timeResource)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := c.Watch(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := drain(ch)
+	if len(events) != 1 || events[0].Type != EventAdded || events[0].Resource.Config.Metadata.Name != "time" {
+		t.Errorf("got initial events %+v", events)
+	}
+
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("channel is not closed after cancel")
+	}
+}
+
+func TestBroadcasterExpired(t *testing.T) {
+	b := newBroadcaster()
+	for i := 0; i < historySize+10; i++ {
+		b.publish(EventModified, &Resource{Config: &config.ResourceConfig{
+			Metadata: config.Metadata{Name: "time", Generation: i + 1},
+		}})
+	}
+	if _, _, _, err := b.subscribe(1); !errors.Is(err, ErrExpired) {
+		t.Errorf("got error %v for sequence out of history, want ErrExpired", err)
+	}
+	_, replay, sequence, err := b.subscribe(b.sequence - 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay) != 5 || sequence != uint64(historySize+10) {
+		t.Errorf("got %d replayed events at sequence %d", len(replay), sequence)
+	}
+}
@@ -1,11 +1,11 @@
 package hosts
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"slices"
 	"strings"
 
 	"go.xbrother.com/nix-operator/pkg/config"
@@ -13,136 +13,117 @@ import (
 	"go.xbrother.com/nix-operator/pkg/utils"
 )
 
+const (
+	hostsPath = "/etc/hosts"
+
+	// defaultHosts 是本地回环地址条目
+	defaultHosts = "127.0.0.1 localhost\n" +
+		"::1 localhost ip6-localhost ip6-loopback\n"
+)
+
 type Config struct {
-	Hosts []hostEntry `json:"hosts"`
+	Hosts []HostEntry `json:"hosts" zh:"静态主机名解析条目" en:"Static hostname entries"`
 }
 
 type HostEntry struct {
-	IP        string   `json:"ip"`
-	Hostnames []string `json:"hostnames"`
+	IP        string   `json:"ip" required:"true" zh:"IP 地址" en:"IP address" placeholder:"192.168.1.10"`
+	Hostnames []string `json:"hostnames" required:"true" format:"hostname" zh:"主机名列表" en:"Hostnames" placeholder:"server.local"`
 }
 
 func init() {
+	controller.RegisterSpec("HostsConfiguration", &Config{})
 	controller.RegisterHandler("HostsConfiguration", &LinuxHostsHandler{})
 }
 
 type LinuxHostsHandler struct{}
 
-type hostEntry struct {
-	IP        string
-	Hostnames []string
-}
-
 func (h *LinuxHostsHandler) Match(osInfo controller.OSInfo) bool {
 	return osInfo.KernelName == "Linux"
 }
 
-func (h *LinuxHostsHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) error {
-	// 读取现有的 hosts 文件
-	currentEntries, err := h.getCurrentHosts()
+func (h *LinuxHostsHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*controller.ReconcileResult, error) {
+	files, err := h.Render(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to read current hosts: %v", err)
+		return nil, err
 	}
+	desired := files[0].Content
+	fsys := utils.FSFromContext(ctx)
 
-	// 转换期望的配置
-	desiredEntries := make([]hostEntry, len(cfg.Spec.Network.Hosts))
-	for i, host := range cfg.Spec.Network.Hosts {
-		desiredEntries[i] = hostEntry{
-			IP:        host.IP,
-			Hostnames: host.Hostnames,
-		}
+	// 读取现有的 hosts 文件并比较
+	current, err := fsys.ReadFile(hostsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read current hosts: %v", err)
+	}
+	if bytes.Equal(current, desired) {
+		return &controller.ReconcileResult{Effective: cfg}, nil // 配置一致，无需更新
 	}
 
-	// 比较现有配置和期望配置
-	if h.areHostsEqual(currentEntries, desiredEntries) {
-		return nil // 配置一致，无需更新
+	// 首次接管前备份原文件
+	if err := utils.BackupFile(fsys, hostsPath); err != nil {
+		return nil, err
+	}
+
+	// 原子性写入文件
+	if err := fsys.WriteFile(hostsPath, desired, 0644); err != nil {
+		return nil, err
+	}
+
+	return &controller.ReconcileResult{Effective: cfg}, nil
+}
+
+// Render 生成期望的 hosts 文件
+func (h *LinuxHostsHandler) Render(ctx context.Context, cfg *config.ResourceConfig) ([]controller.RenderedFile, error) {
+	// 解析 hosts 配置
+	var hostsSpec Config
+	if err := json.Unmarshal(cfg.Spec, &hostsSpec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal hosts spec: %v", err)
 	}
 
 	// 生成新的 hosts 内容
 	var content strings.Builder
 	content.WriteString(config.CommentHeader)
-	content.WriteString("127.0.0.1 localhost\n")
-	content.WriteString("::1 localhost ip6-localhost ip6-loopback\n\n")
+	content.WriteString(defaultHosts)
+	content.WriteString("\n")
 
-	for _, host := range desiredEntries {
+	for _, host := range hostsSpec.Hosts {
 		content.WriteString(fmt.Sprintf("%s %s\n", host.IP, strings.Join(host.Hostnames, " ")))
 	}
 
-	// 原子性写入文件
-	return utils.AtomicWriteFile([]byte(content.String()), "/etc/hosts", 0644)
+	return []controller.RenderedFile{{Path: hostsPath, Content: []byte(content.String()), Mode: 0644}}, nil
 }
 
-func (h *LinuxHostsHandler) getCurrentHosts() ([]hostEntry, error) {
-	file, err := os.Open("/etc/hosts")
+// Plan 预览 hosts 文件的变更，hosts 文件无需重新加载
+func (h *LinuxHostsHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*controller.Plan, error) {
+	files, err := h.Render(ctx, cfg)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []hostEntry{}, nil
-		}
 		return nil, err
 	}
-	defer file.Close()
-
-	var entries []hostEntry
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// 跳过空行和注释
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) >= 2 {
-			entries = append(entries, hostEntry{
-				IP:        fields[0],
-				Hostnames: fields[1:],
-			})
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return entries, nil
+	return controller.NewPlan(ctx, files)
 }
 
-func (h *LinuxHostsHandler) areHostsEqual(current, desired []hostEntry) bool {
-	if len(current) != len(desired) {
-		return false
-	}
-
-	// 复制切片以避免修改原始数据
-	currentCopy := make([]hostEntry, len(current))
-	desiredCopy := make([]hostEntry, len(desired))
-	copy(currentCopy, current)
-	copy(desiredCopy, desired)
+// Exclusive hosts 文件只有一个，只允许一个 HostsConfiguration 资源
+func (h *LinuxHostsHandler) Exclusive() bool {
+	return true
+}
 
-	// 对每个条目的主机名进行排序
-	for i := range currentCopy {
-		slices.Sort(currentCopy[i].Hostnames)
-	}
-	for i := range desiredCopy {
-		slices.Sort(desiredCopy[i].Hostnames)
+// Cleanup 恢复 nix-operator 接管前的 hosts 文件
+func (h *LinuxHostsHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	fsys := utils.FSFromContext(ctx)
+	restored, err := utils.RestoreFile(fsys, hostsPath)
+	if err != nil || restored {
+		return err
 	}
 
-	// 对条目进行排序（按IP地址）
-	slices.SortFunc(currentCopy, func(a, b hostEntry) int {
-		return strings.Compare(a.IP, b.IP)
-	})
-	slices.SortFunc(desiredCopy, func(a, b hostEntry) int {
-		return strings.Compare(a.IP, b.IP)
-	})
-
-	// 比较每个条目
-	for i := range currentCopy {
-		if currentCopy[i].IP != desiredCopy[i].IP {
-			return false
-		}
-		if !slices.Equal(currentCopy[i].Hostnames, desiredCopy[i].Hostnames) {
-			return false
+	// 没有备份时，仅保留本地回环地址
+	current, err := fsys.ReadFile(hostsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
+		return fmt.Errorf("failed to read current hosts: %v", err)
 	}
-
-	return true
+	if !utils.IsGenerated(current) {
+		return nil
+	}
+	return fsys.WriteFile(hostsPath, []byte(defaultHosts), 0644)
 }
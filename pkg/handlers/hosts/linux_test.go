@@ -0,0 +1,62 @@
+package hosts
+
+import (
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+const originalHosts = "127.0.0.1 localhost\n10.0.0.1 legacy\n"
+
+func testConfig() *config.ResourceConfig {
+	return &config.ResourceConfig{
+		Kind:     "HostsConfiguration",
+		Metadata: config.Metadata{Name: "hosts"},
+		Spec: []byte(`{"hosts": [
+			{"ip": "192.168.1.10", "hostnames": ["host1.example.com", "host1"]},
+			{"ip": "192.168.1.11", "hostnames": ["host2.example.com", "host2"]}
+		]}`),
+	}
+}
+
+func TestRender(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc")
+	h := &LinuxHostsHandler{}
+
+	files, err := h.Render(testutil.Context(fsys, &testutil.FakeRunner{}), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != hostsPath {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	testutil.Golden(t, "hosts", files[0].Content)
+}
+
+func TestReconcileAndCleanup(t *testing.T) {
+	fsys := testutil.NewRoot(t)
+	testutil.WriteFile(t, fsys, hostsPath, originalHosts)
+	ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+	h := &LinuxHostsHandler{}
+
+	if _, err := h.Reconcile(ctx, testConfig()); err != nil {
+		t.Fatal(err)
+	}
+	written, err := fsys.ReadFile(hostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Golden(t, "hosts", written)
+
+	if err := h.Cleanup(ctx, testConfig()); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fsys.ReadFile(hostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(restored) != originalHosts {
+		t.Errorf("hosts not restored after cleanup, got:\n%s", restored)
+	}
+}
@@ -0,0 +1,26 @@
+package hosts
+
+import (
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// Validate 校验 hosts 条目的 IP 地址和主机名
+func (c *Config) Validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	for i, host := range c.Hosts {
+		hostPath := path.Child("hosts").Index(i)
+		if host.IP == "" {
+			errs = append(errs, validation.Required(hostPath.Child("ip")))
+		} else {
+			errs = append(errs, validation.ValidateIP(hostPath.Child("ip"), host.IP)...)
+		}
+
+		if len(host.Hostnames) == 0 {
+			errs = append(errs, validation.Required(hostPath.Child("hostnames")))
+		}
+		for j, hostname := range host.Hostnames {
+			errs = append(errs, validation.ValidateHostname(hostPath.Child("hostnames").Index(j), hostname)...)
+		}
+	}
+	return errs
+}
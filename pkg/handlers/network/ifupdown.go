@@ -4,15 +4,17 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	_ "embed"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/template"
-	_ "embed"
 
 	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/controller"
 	"go.xbrother.com/nix-operator/pkg/utils"
 )
 
@@ -21,68 +23,115 @@ type Ifupdown struct{}
 //go:embed ifupdown.tpl
 var ifupdownTemplate string
 
+func (ifd *Ifupdown) Name() string {
+	return "ifupdown"
+}
+
 func (ifd *Ifupdown) IsInstall(ctx context.Context) bool {
-	_, err := os.Stat("/sbin/ifup")
+	_, err := utils.FSFromContext(ctx).Stat("/sbin/ifup")
 	return err == nil
 }
 
-func (ifd *Ifupdown) findConfig(iface config.Interface) (string, error) {
-	// 检查主配置文件
-	mainConfig, err := os.ReadFile("/etc/network/interfaces")
-	if err == nil {
-		scanner := bufio.NewScanner(strings.NewReader(string(mainConfig)))
-		for scanner.Scan() {
-			if strings.Contains(scanner.Text(), fmt.Sprintf("iface %s ", iface.Name)) {
-				return "/etc/network/interfaces", nil
-			}
-		}
-	}
+// errSharedConfig 表示接口定义在同时包含其他接口的文件中，覆盖该文件会丢失其他接口的配置
+var errSharedConfig = errors.New("interface is defined together with other interfaces")
 
-	// 检查 interfaces.d 目录
-	files, err := os.ReadDir("/etc/network/interfaces.d")
+// findConfig 返回定义接口的配置文件，未定义时为 interfaces.d/<name>
+// 接口与其他接口（如 lo）定义在同一个文件中时拒绝接管，需要先将其移到单独的文件
+func (ifd *Ifupdown) findConfig(fsys utils.FS, iface Interface) (string, error) {
+	paths := []string{"/etc/network/interfaces"}
+	files, err := fsys.ReadDir("/etc/network/interfaces.d")
 	if err != nil {
 		return "", fmt.Errorf("failed to read interfaces.d directory: %v", err)
 	}
-
 	for _, file := range files {
-		if file.IsDir() {
-			continue
+		if !file.IsDir() {
+			paths = append(paths, filepath.Join("/etc/network/interfaces.d", file.Name()))
 		}
+	}
 
-		path := filepath.Join("/etc/network/interfaces.d", file.Name())
-		data, err := os.ReadFile(path)
+	for _, path := range paths {
+		data, err := fsys.ReadFile(path)
 		if err != nil {
 			continue
 		}
-
-		if strings.Contains(string(data), fmt.Sprintf("iface %s ", iface.Name)) {
-			return path, nil
+		names := stanzaInterfaces(data)
+		if !slices.Contains(names, iface.Name) {
+			continue
 		}
+		for _, name := range names {
+			if name != iface.Name {
+				return "", fmt.Errorf("%w: %s is also configured in %s, move it to /etc/network/interfaces.d/%s",
+					errSharedConfig, iface.Name, path, iface.Name)
+			}
+		}
+		return path, nil
 	}
 
 	return fmt.Sprintf("/etc/network/interfaces.d/%s", iface.Name), nil
 }
 
-func (ifd *Ifupdown) Configure(ctx context.Context, iface config.Interface) error {
-	configPath, err := ifd.findConfig(iface)
+// stanzaInterfaces 返回 interfaces 文件中 iface、mapping、auto 和 allow-* 段涉及的接口
+func stanzaInterfaces(data []byte) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var stanza []string
+		switch {
+		case fields[0] == "iface" || fields[0] == "mapping":
+			stanza = fields[1:2]
+		case fields[0] == "auto" || strings.HasPrefix(fields[0], "allow-"):
+			stanza = fields[1:]
+		}
+		for _, name := range stanza {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// checkVLANName 检查 VLAN 接口名，ifupdown 的 vlan 软件包从接口名中获取 VLAN ID，不支持 vlan-id 选项
+func checkVLANName(iface Interface) error {
+	if iface.VLAN == nil {
+		return nil
+	}
+	dotted := fmt.Sprintf("%s.%d", iface.VLAN.Link, iface.VLAN.ID)
+	if iface.Name != dotted && iface.Name != fmt.Sprintf("vlan%d", iface.VLAN.ID) {
+		return fmt.Errorf("ifupdown requires VLAN interface %s to be named %s or vlan%d", iface.Name, dotted, iface.VLAN.ID)
+	}
+	return nil
+}
+
+func (ifd *Ifupdown) Render(ctx context.Context, iface Interface) (controller.RenderedFile, error) {
+	if err := checkVLANName(iface); err != nil {
+		return controller.RenderedFile{}, err
+	}
+	configPath, err := ifd.findConfig(utils.FSFromContext(ctx), iface)
 	if err != nil {
-		return err
+		return controller.RenderedFile{}, err
 	}
 
 	// 创建模板并添加自定义函数
 	tmpl := template.New("ifupdown").Funcs(template.FuncMap{
-		"join": strings.Join,
+		"join":    strings.Join,
+		"list":    func(values ...any) []any { return values },
+		"ipRoute": ipRoute,
 	})
 
 	tmpl, err = tmpl.Parse(ifupdownTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %v", err)
+		return controller.RenderedFile{}, fmt.Errorf("failed to parse template: %v", err)
 	}
 
 	// 准备模板数据
 	data := struct {
 		CommentHeader string
-		Interface     config.Interface
+		Interface     Interface
 	}{
 		CommentHeader: config.CommentHeader,
 		Interface:     iface,
@@ -91,27 +140,79 @@ func (ifd *Ifupdown) Configure(ctx context.Context, iface config.Interface) erro
 	// 渲染模板
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("failed to execute template: %v", err)
+		return controller.RenderedFile{}, fmt.Errorf("failed to execute template: %v", err)
 	}
 
-	desired := buf.String()
+	return controller.RenderedFile{Path: configPath, Content: buf.Bytes(), Mode: 0644}, nil
+}
+
+func (ifd *Ifupdown) Configure(ctx context.Context, iface Interface) (bool, error) {
+	file, err := ifd.Render(ctx, iface)
+	if err != nil {
+		return false, err
+	}
+
+	fsys := utils.FSFromContext(ctx)
 
 	// 读取现有配置
-	current, err := os.ReadFile(configPath)
-	if err == nil && string(current) == desired {
-		return nil // 配置相同，无需更新
+	current, err := fsys.ReadFile(file.Path)
+	if err == nil && bytes.Equal(current, file.Content) {
+		return false, nil // 配置相同，无需更新
+	}
+
+	// 首次接管前备份原文件
+	if err := utils.BackupFile(fsys, file.Path); err != nil {
+		return false, err
 	}
 
-	return utils.AtomicWriteFile([]byte(desired), configPath, 0644)
+	// 写入新配置
+	if err := fsys.WriteFile(file.Path, file.Content, file.Mode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ifd *Ifupdown) Cleanup(ctx context.Context, iface Interface) (bool, error) {
+	fsys := utils.FSFromContext(ctx)
+	configPath, err := ifd.findConfig(fsys, iface)
+	if errors.Is(err, errSharedConfig) {
+		return false, nil // 从未接管过共享的文件
+	}
+	if err != nil {
+		return false, err
+	}
+	return utils.RevertFile(fsys, configPath)
+}
+
+func (ifd *Ifupdown) ReloadCommand() []string {
+	return []string{"systemctl", "restart", "networking"}
 }
 
 func (ifd *Ifupdown) ReloadIfy(ctx context.Context) error {
 	if !isServiceActive(ctx, "networking") {
 		return nil
 	}
-	cmd := exec.CommandContext(ctx, "systemctl", "restart", "networking")
-	if output, err := cmd.CombinedOutput(); err != nil {
+	args := ifd.ReloadCommand()
+	if output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...); err != nil {
 		return fmt.Errorf("failed to restart networking: %v, output: %s", err, output)
 	}
 	return nil
 }
+
+// ipRoute 返回 ip route add 的目标网段和路由参数
+func ipRoute(route Route) string {
+	args := []string{route.To}
+	if route.Via != "" {
+		args = append(args, "via", route.Via)
+	}
+	if route.Metric != 0 {
+		args = append(args, "metric", strconv.Itoa(route.Metric))
+	}
+	if route.Table != 0 {
+		args = append(args, "table", strconv.Itoa(route.Table))
+	}
+	if route.OnLink {
+		args = append(args, "onlink")
+	}
+	return strings.Join(args, " ")
+}
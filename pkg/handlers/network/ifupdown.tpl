@@ -1,22 +1,88 @@
 {{.CommentHeader}}auto {{.Interface.Name}}
-{{- if .Interface.IPAddress}}
-iface {{.Interface.Name}} inet static
-    address {{.Interface.IPAddress}}
+{{- $name := .Interface.Name}}
+{{- $v4 := .Interface.IPv4Mode}}
+{{- $v6 := .Interface.IPv6Mode}}
+{{- $v4addrs := .Interface.IPv4Addresses}}
+{{- $v6addrs := .Interface.IPv6Addresses}}
+{{- $first := or (ne $v4 "disabled") (eq $v6 "disabled")}}
+{{- if eq $v4 "static"}}
+iface {{$name}} inet static
+    address {{index $v4addrs 0}}
 {{- if .Interface.Gateway}}
     gateway {{.Interface.Gateway}}
 {{- end}}
-{{- if .Interface.Nameservers}}
-    dns-nameservers {{join .Interface.Nameservers " "}}
+{{- $v4addrs = slice $v4addrs 1}}
+{{- else if eq $v4 "dhcp"}}
+iface {{$name}} inet dhcp
+{{- with .Interface.DHCP}}
+{{- if .Hostname}}
+    hostname {{.Hostname}}
 {{- end}}
+{{- if .RouteMetric}}
+    metric {{.RouteMetric}}
 {{- end}}
-{{- if .Interface.IPv6Address}}
-iface {{.Interface.Name}} inet6 static
-    address {{.Interface.IPv6Address}}
+{{- end}}
+{{- else if eq $v6 "disabled"}}
+iface {{$name}} inet manual
+{{- end}}
+{{- template "up" (list $name $v4addrs .Interface.IPv4Routes)}}
+{{- if $first}}
+{{- template "options" .Interface}}
+{{- end}}
+{{- if eq $v6 "static"}}
+iface {{$name}} inet6 static
+    address {{index $v6addrs 0}}
 {{- if .Interface.IPv6Gateway}}
     gateway {{.Interface.IPv6Gateway}}
 {{- end}}
-{{- if and .Interface.Nameservers (not .Interface.IPAddress)}}
-    dns-nameservers {{join .Interface.Nameservers " "}}
+{{- $v6addrs = slice $v6addrs 1}}
+{{- else if eq $v6 "dhcp"}}
+iface {{$name}} inet6 dhcp
+{{- else if eq $v6 "slaac"}}
+iface {{$name}} inet6 auto
+{{- else if eq $v6 "auto"}}
+iface {{$name}} inet6 auto
+    dhcp 1
+{{- end}}
+{{- template "up" (list $name $v6addrs .Interface.IPv6Routes)}}
+{{- if not $first}}
+{{- template "options" .Interface}}
+{{- end}}
+{{- define "up"}}
+{{- $name := index . 0}}
+{{- range index . 1}}
+    up ip addr add {{.}} dev {{$name}}
+{{- end}}
+{{- range index . 2}}
+    up ip route add {{ipRoute .}} dev {{$name}}
+{{- end}}
+{{- end}}
+{{- define "options"}}
+{{- with .VLAN}}
+    vlan-raw-device {{.Link}}
+{{- end}}
+{{- with .Bond}}
+    bond-slaves {{join .Members " "}}
+    bond-mode {{.Mode}}
+    bond-miimon {{.MIIMon}}
+{{- if .Primary}}
+    bond-primary {{.Primary}}
+{{- end}}
+{{- end}}
+{{- with .Bridge}}
+    bridge_ports {{if .Members}}{{join .Members " "}}{{else}}none{{end}}
+    bridge_stp {{if .STP}}on{{else}}off{{end}}
+{{- if .ForwardDelay}}
+    bridge_fd {{.ForwardDelay}}
+{{- end}}
+{{- end}}
+{{- if eq .ControllerType "bond"}}
+    bond-master {{.Controller}}
+{{- end}}
+{{- if .Nameservers}}
+    dns-nameservers {{join .Nameservers " "}}
 {{- end}}
+{{- if .MTU}}
+    mtu {{.MTU}}
 {{- end}}
-    mtu {{.Interface.MTU}}
\ No newline at end of file
+{{- end}}
\ No newline at end of file
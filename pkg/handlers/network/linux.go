@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"time"
 
 	"go.xbrother.com/nix-operator/pkg/config"
 	"go.xbrother.com/nix-operator/pkg/controller"
@@ -11,19 +13,248 @@ import (
 )
 
 type Config struct {
-	Interfaces []Interface `json:"interfaces"`
+	Interfaces []Interface `json:"interfaces" zh:"网络接口" en:"Network interfaces"`
 }
 
 type Interface struct {
-	NodeSelector utils.NodeSelector `json:"nodeSelector"`
-	Name         string             `json:"name"`
-	IPAddress    string             `json:"ipAddress"`   // IPv4 地址
-	IPv6Address  string             `json:"ipv6Address"` // IPv6 地址
-	Gateway      string             `json:"gateway"`     // IPv4 网关
-	IPv6Gateway  string             `json:"ipv6Gateway"` // IPv6 网关
-	MTU          int                `json:"mtu"`
-	MACAddress   string             `json:"macAddress"`
-	Nameservers  []string           `json:"nameservers"`
+	NodeSelector utils.NodeSelector `json:"nodeSelector" zh:"节点选择器，只在匹配的设备上生效" en:"Node selector, applies only on matching devices"`
+	Name         string             `json:"name" required:"true" pattern:"^[A-Za-z0-9_.:-]{1,15}$" zh:"接口名称" en:"Interface name" placeholder:"eth0"`
+	VLAN         *VLANConfig        `json:"vlan,omitempty" zh:"802.1Q VLAN 配置，设置后接口为父接口上的 VLAN 子接口" en:"802.1Q VLAN settings, makes the interface a VLAN on the parent link"`
+	Bond         *BondConfig        `json:"bond,omitempty" zh:"链路聚合配置，设置后接口为聚合成员接口的 bond" en:"Bonding settings, makes the interface a bond of the member links"`
+	Bridge       *BridgeConfig      `json:"bridge,omitempty" zh:"网桥配置，设置后接口为连接成员接口的 Linux 网桥" en:"Bridge settings, makes the interface a Linux bridge of the member links"`
+	IPv4Method   string             `json:"ipv4Method" enum:"static,dhcp,disabled" zh:"IPv4 地址获取方式，为空时有地址则为 static，否则为 disabled" en:"IPv4 addressing mode; when empty, static if an address is set, otherwise disabled"`
+	IPAddress    string             `json:"ipAddress" zh:"IPv4 地址（CIDR 格式）" en:"IPv4 address in CIDR notation" placeholder:"192.168.1.100/24"`
+	IPv6Method   string             `json:"ipv6Method" enum:"static,slaac,dhcp,auto,disabled" zh:"IPv6 地址获取方式，auto 按路由通告使用 SLAAC 和 DHCPv6，为空时有地址则为 static，否则为 disabled" en:"IPv6 addressing mode; auto uses SLAAC and DHCPv6 as router advertisements indicate; when empty, static if an address is set, otherwise disabled"`
+	IPv6Address  string             `json:"ipv6Address" zh:"IPv6 地址（CIDR 格式）" en:"IPv6 address in CIDR notation" placeholder:"2001:db8::1/64"`
+	Gateway      string             `json:"gateway" format:"ipv4" zh:"IPv4 网关" en:"IPv4 gateway" placeholder:"192.168.1.1"`
+	IPv6Gateway  string             `json:"ipv6Gateway" format:"ipv6" zh:"IPv6 网关" en:"IPv6 gateway" placeholder:"2001:db8::ffff"`
+	Addresses    []string           `json:"addresses" zh:"附加的 IPv4 或 IPv6 地址（CIDR 格式）" en:"Additional IPv4 or IPv6 addresses in CIDR notation" placeholder:"192.168.1.101/24"`
+	Routes       []Route            `json:"routes" zh:"静态路由" en:"Static routes"`
+	MTU          int                `json:"mtu" ui:"updown" minimum:"68" maximum:"65535" zh:"最大传输单元" en:"Maximum transmission unit" placeholder:"1500"`
+	MACAddress   string             `json:"macAddress" zh:"MAC 地址" en:"MAC address" placeholder:"00:11:22:33:44:55"`
+	Nameservers  []string           `json:"nameservers" zh:"DNS 服务器" en:"DNS servers" placeholder:"8.8.8.8"`
+	DHCP         *DHCPConfig        `json:"dhcp,omitempty" zh:"DHCP 客户端配置" en:"DHCP client settings"`
+
+	// bond 或网桥的成员接口所属的接口及其类型，由 expandMembers 设置
+	controller     string
+	controllerType string
+}
+
+// VLANConfig 描述 VLAN 子接口所在的父接口和 VLAN ID
+// ifupdown 的 vlan 软件包从接口名中获取 VLAN ID，要求接口名为 <父接口>.<ID> 或 vlan<ID>
+type VLANConfig struct {
+	Link string `json:"link" required:"true" pattern:"^[A-Za-z0-9_.:-]{1,15}$" zh:"父接口名称" en:"Parent interface name" placeholder:"eth0"`
+	ID   int    `json:"id" required:"true" ui:"updown" minimum:"1" maximum:"4094" zh:"VLAN ID" en:"VLAN ID" placeholder:"100"`
+}
+
+// BondConfig 描述 bond 的成员接口和聚合参数
+type BondConfig struct {
+	Mode    string   `json:"mode" enum:"balance-rr,active-backup,balance-xor,broadcast,802.3ad,balance-tlb,balance-alb" zh:"聚合模式" en:"Bonding mode" default:"active-backup"`
+	MIIMon  int      `json:"miimon" ui:"updown" minimum:"0" zh:"链路检测间隔（毫秒）" en:"MII link monitoring interval (milliseconds)" default:"100"`
+	Primary string   `json:"primary" zh:"主用成员接口，active-backup 等模式下优先使用" en:"Preferred member link in active-backup and similar modes" placeholder:"eth0"`
+	Members []string `json:"members" required:"true" zh:"成员接口名称" en:"Member interface names" placeholder:"eth0"`
+}
+
+// BridgeConfig 描述网桥的成员接口和生成树参数
+type BridgeConfig struct {
+	Members      []string `json:"members" zh:"成员接口名称，为空时只创建网桥" en:"Member interface names, creates an empty bridge when empty" placeholder:"eth0"`
+	STP          bool     `json:"stp" zh:"启用生成树协议" en:"Enable the spanning tree protocol"`
+	ForwardDelay int      `json:"forwardDelay" ui:"updown" minimum:"0" maximum:"30" zh:"转发延迟（秒），为 0 时使用默认值" en:"Forward delay (seconds), the backend default when 0" placeholder:"15"`
+}
+
+// 未设置时 bond 使用的模式和链路检测间隔
+const (
+	DefaultBondMode   = "active-backup"
+	DefaultBondMIIMon = 100
+)
+
+// 接口类型，与 NetworkManager 的连接类型一致
+const (
+	TypeEthernet = "ethernet"
+	TypeVLAN     = "vlan"
+	TypeBond     = "bond"
+	TypeBridge   = "bridge"
+)
+
+// Type 返回接口类型
+func (iface Interface) Type() string {
+	switch {
+	case iface.VLAN != nil:
+		return TypeVLAN
+	case iface.Bond != nil:
+		return TypeBond
+	case iface.Bridge != nil:
+		return TypeBridge
+	}
+	return TypeEthernet
+}
+
+// Controller 返回成员接口所属的 bond 或网桥，不是成员时返回空
+func (iface Interface) Controller() string {
+	return iface.controller
+}
+
+// ControllerType 返回成员接口所属接口的类型，bond 或 bridge
+func (iface Interface) ControllerType() string {
+	return iface.controllerType
+}
+
+// Members 返回 bond 或网桥的成员接口
+func (iface Interface) Members() []string {
+	switch {
+	case iface.Bond != nil:
+		return iface.Bond.Members
+	case iface.Bridge != nil:
+		return iface.Bridge.Members
+	}
+	return nil
+}
+
+// expandMembers 返回需要生成配置的所有接口：为 bond 补全默认参数，
+// 将成员接口关联到所属的 bond 或网桥，未单独声明的成员接口追加在最后
+func expandMembers(ifaces []Interface) []Interface {
+	devices := make([]Interface, len(ifaces))
+	copy(devices, ifaces)
+	index := make(map[string]int, len(devices))
+	for i, iface := range devices {
+		index[iface.Name] = i
+	}
+
+	for i := range devices {
+		if devices[i].Bond != nil {
+			bond := *devices[i].Bond
+			if bond.Mode == "" {
+				bond.Mode = DefaultBondMode
+			}
+			if bond.MIIMon == 0 {
+				bond.MIIMon = DefaultBondMIIMon
+			}
+			devices[i].Bond = &bond
+		}
+
+		controller, controllerType := devices[i].Name, devices[i].Type()
+		for _, member := range devices[i].Members() {
+			j, ok := index[member]
+			if !ok {
+				j = len(devices)
+				index[member] = j
+				devices = append(devices, Interface{NodeSelector: devices[i].NodeSelector, Name: member})
+			}
+			devices[j].controller, devices[j].controllerType = controller, controllerType
+		}
+	}
+	return devices
+}
+
+// Route 是经接口到达目标网段的静态路由，IPv4 或 IPv6 由目标网段决定
+type Route struct {
+	To     string `json:"to" required:"true" zh:"目标网段（CIDR 格式）" en:"Destination in CIDR notation" placeholder:"10.10.0.0/16"`
+	Via    string `json:"via" zh:"下一跳地址，为空时目标网段直连" en:"Next hop address, the destination is directly connected when empty" placeholder:"192.168.1.254"`
+	Metric int    `json:"metric" ui:"updown" minimum:"0" zh:"路由优先级，值越小越优先" en:"Route metric, lower is preferred"`
+	Table  int    `json:"table" ui:"updown" minimum:"0" zh:"路由表编号，为 0 时使用主路由表" en:"Routing table ID, the main table when 0"`
+	OnLink bool   `json:"onLink" zh:"下一跳不在接口地址的网段内时仍视为直连" en:"Treat the next hop as directly reachable even if it is outside the interface subnets"`
+}
+
+// 地址获取方式
+const (
+	AddressStatic   = "static"
+	AddressDHCP     = "dhcp"
+	AddressSLAAC    = "slaac"
+	AddressAuto     = "auto"
+	AddressDisabled = "disabled"
+)
+
+// DHCPConfig 同时作用于 DHCPv4 和 DHCPv6
+type DHCPConfig struct {
+	ClientID     string `json:"clientId" enum:"mac,duid" zh:"DHCPv4 客户端标识，ifupdown 始终使用 MAC" en:"DHCPv4 client identifier, ifupdown always uses the MAC address"`
+	SendHostname *bool  `json:"sendHostname,omitempty" zh:"是否向 DHCP 服务器发送主机名，ifupdown 不支持关闭" en:"Send the hostname to the DHCP server, cannot be disabled with ifupdown" default:"true"`
+	Hostname     string `json:"hostname" zh:"发送的主机名，为空时使用本机主机名" en:"Hostname to send, defaults to the system hostname" placeholder:"node-1"`
+	RouteMetric  int    `json:"routeMetric" ui:"updown" minimum:"0" zh:"DHCP 获取的路由的优先级，值越小越优先" en:"Metric of routes learned via DHCP, lower is preferred" placeholder:"100"`
+	IgnoreDNS    bool   `json:"ignoreDNS" zh:"忽略 DHCP 下发的 DNS 服务器，ifupdown 不支持" en:"Ignore DNS servers from DHCP, not supported by ifupdown"`
+}
+
+// IPv4Mode 返回 IPv4 的地址获取方式，未设置时按是否配置了地址推断
+func (iface Interface) IPv4Mode() string {
+	return addressMode(iface.IPv4Method, len(iface.IPv4Addresses()) > 0)
+}
+
+// IPv6Mode 返回 IPv6 的地址获取方式，未设置时按是否配置了地址推断
+func (iface Interface) IPv6Mode() string {
+	return addressMode(iface.IPv6Method, len(iface.IPv6Addresses()) > 0)
+}
+
+// IPv4Addresses 返回 ipAddress 和 addresses 中的 IPv4 地址
+func (iface Interface) IPv4Addresses() []string {
+	return familyAddresses(false, iface.IPAddress, iface.Addresses)
+}
+
+// IPv6Addresses 返回 ipv6Address 和 addresses 中的 IPv6 地址
+func (iface Interface) IPv6Addresses() []string {
+	return familyAddresses(true, iface.IPv6Address, iface.Addresses)
+}
+
+// IPv4Routes 返回目标为 IPv4 网段的路由
+func (iface Interface) IPv4Routes() []Route {
+	return familyRoutes(false, iface.Routes)
+}
+
+// IPv6Routes 返回目标为 IPv6 网段的路由
+func (iface Interface) IPv6Routes() []Route {
+	return familyRoutes(true, iface.Routes)
+}
+
+// DHCPv6 返回是否启用 DHCPv6 客户端
+func (iface Interface) DHCPv6() bool {
+	mode := iface.IPv6Mode()
+	return mode == AddressDHCP || mode == AddressAuto
+}
+
+// AcceptRA 返回是否通过路由通告自动配置 IPv6 地址
+func (iface Interface) AcceptRA() bool {
+	mode := iface.IPv6Mode()
+	return mode == AddressSLAAC || mode == AddressAuto
+}
+
+func addressMode(method string, hasAddress bool) string {
+	switch {
+	case method != "":
+		return method
+	case hasAddress:
+		return AddressStatic
+	}
+	return AddressDisabled
+}
+
+// isIPv6Prefix 返回 CIDR 格式的地址是否为 IPv6，无法解析时 ok 为 false
+func isIPv6Prefix(s string) (ipv6, ok bool) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return false, false
+	}
+	return prefix.Addr().Is6(), true
+}
+
+func familyAddresses(ipv6 bool, primary string, addresses []string) []string {
+	var result []string
+	if primary != "" {
+		result = append(result, primary)
+	}
+	for _, address := range addresses {
+		if is6, ok := isIPv6Prefix(address); ok && is6 == ipv6 {
+			result = append(result, address)
+		}
+	}
+	return result
+}
+
+func familyRoutes(ipv6 bool, routes []Route) []Route {
+	var result []Route
+	for _, route := range routes {
+		if is6, ok := isIPv6Prefix(route.To); ok && is6 == ipv6 {
+			result = append(result, route)
+		}
+	}
+	return result
 }
 
 func init() {
@@ -34,18 +265,179 @@ func init() {
 			&Ifupdown{},
 		},
 	}
+	controller.RegisterSpec("NetworkConfiguration", &Config{})
 	controller.RegisterHandler("NetworkConfiguration", handler)
 }
 
 type LinuxNetworkHandler struct {
 	managers []INetworkManager
+
+	// reloads 记录配置已写入但重新加载失败的网络管理器，重试时再次重新加载
+	reloads utils.PendingReloads
 }
 
 func (h *LinuxNetworkHandler) Match(osInfo controller.OSInfo) bool {
 	return osInfo.KernelName == "Linux"
 }
 
-func (h *LinuxNetworkHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) error {
+func (h *LinuxNetworkHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*controller.ReconcileResult, error) {
+	// 仅保留匹配当前节点的接口作为生效配置
+	matched, err := h.matchedInterfaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 为每个已安装的网络管理器生成配置，配置变化时重新加载一次
+	devices := expandMembers(matched)
+	for _, manager := range h.managers {
+		if !manager.IsInstall(ctx) {
+			continue
+		}
+
+		logger := utils.LoggerFromContext(ctx).With("backend", manager.Name())
+		// 先渲染所有接口，任一接口无法接管（如配置文件与其他接口共用）时不写入任何文件
+		for _, iface := range devices {
+			if _, err := manager.Render(ctx, iface); err != nil {
+				logger.Error("Failed to render interface", "interface", iface.Name, "error", err)
+				return nil, err
+			}
+		}
+
+		var changed bool
+		for _, iface := range devices {
+			ifaceChanged, err := manager.Configure(ctx, iface)
+			if err != nil {
+				logger.Error("Failed to configure interface", "interface", iface.Name, "error", err)
+				return nil, err
+			}
+			if ifaceChanged {
+				logger.Info("Configured interface", "interface", iface.Name)
+			}
+			changed = changed || ifaceChanged
+		}
+
+		if err := h.reload(ctx, manager, changed); err != nil {
+			return nil, err
+		}
+	}
+
+	effective, err := effectiveConfig(cfg, Config{Interfaces: matched})
+	if err != nil {
+		return nil, err
+	}
+	return &controller.ReconcileResult{Effective: effective}, nil
+}
+
+// Render 为每个已安装的网络管理器生成匹配接口的期望配置文件
+func (h *LinuxNetworkHandler) Render(ctx context.Context, cfg *config.ResourceConfig) ([]controller.RenderedFile, error) {
+	matched, err := h.matchedInterfaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := expandMembers(matched)
+	var files []controller.RenderedFile
+	for _, manager := range h.managers {
+		if !manager.IsInstall(ctx) {
+			continue
+		}
+		for _, iface := range devices {
+			file, err := manager.Render(ctx, iface)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// Plan 预览每个已安装的网络管理器的配置变更，以及配置变化时的重新加载命令
+func (h *LinuxNetworkHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*controller.Plan, error) {
+	matched, err := h.matchedInterfaces(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := expandMembers(matched)
+	plan := &controller.Plan{}
+	for _, manager := range h.managers {
+		if !manager.IsInstall(ctx) {
+			continue
+		}
+
+		var files []controller.RenderedFile
+		for _, iface := range devices {
+			file, err := manager.Render(ctx, iface)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file)
+		}
+
+		managerPlan, err := controller.NewPlan(ctx, files)
+		if err != nil {
+			return nil, err
+		}
+		if len(managerPlan.Files) > 0 {
+			plan.Files = append(plan.Files, managerPlan.Files...)
+			plan.Commands = append(plan.Commands, manager.ReloadCommand())
+		}
+	}
+	return plan, nil
+}
+
+// Cleanup 撤销为匹配接口生成的网络配置，并重新加载发生变化的网络管理器
+func (h *LinuxNetworkHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	matched, err := h.matchedInterfaces(cfg)
+	if err != nil {
+		return err
+	}
+
+	devices := expandMembers(matched)
+	for _, manager := range h.managers {
+		if !manager.IsInstall(ctx) {
+			continue
+		}
+
+		logger := utils.LoggerFromContext(ctx).With("backend", manager.Name())
+		var changed bool
+		for _, iface := range devices {
+			ifaceChanged, err := manager.Cleanup(ctx, iface)
+			if err != nil {
+				logger.Error("Failed to clean up interface", "interface", iface.Name, "error", err)
+				return err
+			}
+			if ifaceChanged {
+				logger.Info("Cleaned up interface", "interface", iface.Name)
+			}
+			changed = changed || ifaceChanged
+		}
+
+		if err := h.reload(ctx, manager, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reload 在配置变化或之前的重新加载失败时重新加载网络管理器
+func (h *LinuxNetworkHandler) reload(ctx context.Context, manager INetworkManager, changed bool) error {
+	if changed {
+		h.reloads.Mark(manager.Name())
+	}
+	return h.reloads.Reload(manager.Name(), func() error {
+		start := time.Now()
+		if err := manager.ReloadIfy(ctx); err != nil {
+			return err
+		}
+		utils.LoggerFromContext(ctx).Info("Reloaded network configuration", "backend", manager.Name(), "duration", time.Since(start))
+		return nil
+	})
+}
+
+// matchedInterfaces 解析网络配置，返回匹配当前节点的接口
+func (h *LinuxNetworkHandler) matchedInterfaces(cfg *config.ResourceConfig) ([]Interface, error) {
 	// 解析网络配置
 	var networkSpec struct {
 		Interfaces []Interface `yaml:"interfaces" json:"interfaces"`
@@ -54,34 +446,33 @@ func (h *LinuxNetworkHandler) Reconcile(ctx context.Context, cfg *config.Resourc
 	// 将Spec转换为网络配置
 	specBytes, err := json.Marshal(cfg.Spec)
 	if err != nil {
-		return fmt.Errorf("failed to marshal spec: %v", err)
+		return nil, fmt.Errorf("failed to marshal spec: %v", err)
 	}
 
 	if err := json.Unmarshal(specBytes, &networkSpec); err != nil {
-		return fmt.Errorf("failed to unmarshal network spec: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal network spec: %v", err)
 	}
 
+	var matched []Interface
 	for _, iface := range networkSpec.Interfaces {
 		match, err := utils.MatchNodeSelector(iface.NodeSelector)
 		if err != nil {
-			return fmt.Errorf("failed to check node selector: %v", err)
+			return nil, fmt.Errorf("failed to check node selector: %v", err)
 		}
-		if !match {
-			continue
+		if match {
+			matched = append(matched, iface)
 		}
+	}
+	return matched, nil
+}
 
-		// 为每个已安装的网络管理器生成配置
-		for _, manager := range h.managers {
-			if !manager.IsInstall(ctx) {
-				continue
-			}
-			if err := manager.Configure(ctx, iface); err != nil {
-				return err
-			}
-			if err := manager.ReloadIfy(ctx); err != nil {
-				return err
-			}
-		}
+// effectiveConfig 使用实际生效的 spec 生成资源配置副本
+func effectiveConfig(cfg *config.ResourceConfig, spec Config) (*config.ResourceConfig, error) {
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal effective spec: %v", err)
 	}
-	return nil
+	effective := *cfg
+	effective.Spec = specBytes
+	return &effective, nil
 }
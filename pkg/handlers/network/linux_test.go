@@ -0,0 +1,480 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+var testInterfaces = map[string]Interface{
+	"static": {
+		Name:        "eth0",
+		IPAddress:   "192.168.1.100/24",
+		Gateway:     "192.168.1.1",
+		MTU:         1500,
+		Nameservers: []string{"8.8.8.8", "8.8.4.4"},
+	},
+	"dual-stack": {
+		Name:        "eth1",
+		IPAddress:   "10.0.0.2/24",
+		IPv6Address: "2001:db8::2/64",
+		Gateway:     "10.0.0.1",
+		IPv6Gateway: "2001:db8::1",
+		MTU:         9000,
+		Nameservers: []string{"10.0.0.53"},
+	},
+	"dual-stack-auto": {
+		Name:        "eth5",
+		IPAddress:   "10.0.5.2/24",
+		Gateway:     "10.0.5.1",
+		IPv6Method:  AddressAuto,
+		MTU:         9000,
+		Nameservers: []string{"10.0.5.53"},
+	},
+	"dhcp": {
+		Name:        "eth2",
+		IPv4Method:  AddressDHCP,
+		IPv6Method:  AddressAuto,
+		MTU:         1500,
+		Nameservers: []string{"10.0.0.53"},
+		DHCP: &DHCPConfig{
+			ClientID:     "mac",
+			SendHostname: new(bool),
+			Hostname:     "node-1",
+			RouteMetric:  200,
+			IgnoreDNS:    true,
+		},
+	},
+	"routes": {
+		Name:        "eth4",
+		IPAddress:   "192.168.1.10/24",
+		IPv6Address: "2001:db8::10/64",
+		Gateway:     "192.168.1.1",
+		Addresses:   []string{"192.168.1.11/24", "2001:db8::11/64", "10.20.0.1/24"},
+		Routes: []Route{
+			{To: "10.10.0.0/16", Via: "192.168.1.254", Metric: 100},
+			{To: "10.30.0.0/16", Via: "172.16.0.1", Table: 200, OnLink: true},
+			{To: "10.40.0.0/16"},
+			{To: "2001:db8:1::/48", Via: "2001:db8::fe"},
+		},
+		MTU: 1500,
+	},
+	"vlan": {
+		Name:        "eth0.100",
+		VLAN:        &VLANConfig{Link: "eth0", ID: 100},
+		IPAddress:   "172.16.100.2/24",
+		Gateway:     "172.16.100.1",
+		MTU:         1496,
+		Nameservers: []string{"172.16.100.53"},
+	},
+	"slaac": {
+		Name:        "eth3",
+		IPv6Method:  AddressSLAAC,
+		MTU:         1500,
+		Nameservers: []string{"2001:db8::53"},
+	},
+}
+
+// newRoot 创建包含各网络管理器配置目录的根文件系统
+func newRoot(t *testing.T) utils.FS {
+	return testutil.NewRoot(t,
+		"/etc/netplan",
+		"/etc/NetworkManager/system-connections",
+		"/etc/network/interfaces.d",
+	)
+}
+
+func TestRender(t *testing.T) {
+	managers := map[string]INetworkManager{
+		"netplan":        &Netplan{},
+		"networkmanager": &NetworkManager{},
+		"ifupdown":       &Ifupdown{},
+	}
+
+	for managerName, manager := range managers {
+		for ifaceName, iface := range testInterfaces {
+			t.Run(managerName+"/"+ifaceName, func(t *testing.T) {
+				file, err := manager.Render(testutil.Context(newRoot(t), &testutil.FakeRunner{}), iface)
+				if err != nil {
+					t.Fatal(err)
+				}
+				testutil.Golden(t, managerName+"-"+ifaceName, file.Content)
+			})
+		}
+	}
+}
+
+func TestNetplanLegacyGateway(t *testing.T) {
+	// netplan 0.103 之前不支持 to: default，仍使用 gateway4/gateway6
+	for version, legacy := range map[string]bool{"0.99-0ubuntu3~20.04.2": true, "0.106.1-7ubuntu0.22.04.2": false, "1:1.0-2": false} {
+		fsys := newRoot(t)
+		testutil.WriteFile(t, fsys, "/var/lib/dpkg/status",
+			"Package: netplan.io\nStatus: install ok installed\nVersion: "+version+"\n\nPackage: nplan\nStatus: install ok installed\nVersion: 0.1\n")
+		if got := legacyGateway(fsys); got != legacy {
+			t.Errorf("netplan %s: got legacy gateway %v, want %v", version, got, legacy)
+		}
+	}
+
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/var/lib/dpkg/status",
+		"Package: netplan.io\nStatus: install ok installed\nVersion: 0.99-0ubuntu3~20.04.2\n")
+	for _, ifaceName := range []string{"static", "dual-stack"} {
+		t.Run(ifaceName, func(t *testing.T) {
+			file, err := (&Netplan{}).Render(testutil.Context(fsys, &testutil.FakeRunner{}), testInterfaces[ifaceName])
+			if err != nil {
+				t.Fatal(err)
+			}
+			testutil.Golden(t, "netplan-legacy-"+ifaceName, file.Content)
+		})
+	}
+}
+
+func TestNetplanExistingConfig(t *testing.T) {
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/etc/netplan/50-cloud-init.yaml",
+		"network:\n  version: 2\n  ethernets:\n    eth0:\n      dhcp4: true\n")
+	ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+	np := &Netplan{}
+
+	changed, err := np.Configure(ctx, testInterfaces["static"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected the existing netplan config to be replaced")
+	}
+	written, err := fsys.ReadFile("/etc/netplan/50-cloud-init.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Golden(t, "netplan-static", written)
+
+	// 再次配置时不应重写文件
+	changed, err = np.Configure(ctx, testInterfaces["static"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("expected no change on second configure")
+	}
+
+	// 内容相同但格式不同的文件被重写，与漂移检查的结果一致
+	reformatted := strings.ReplaceAll(strings.TrimPrefix(string(written), config.CommentHeader), "    ", "  ")
+	testutil.WriteFile(t, fsys, "/etc/netplan/50-cloud-init.yaml", reformatted)
+	changed, err = np.Configure(ctx, testInterfaces["static"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("expected the reformatted config to be rewritten")
+	}
+	rewritten, err := fsys.ReadFile("/etc/netplan/50-cloud-init.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Golden(t, "netplan-static", rewritten)
+
+	// 清理后恢复接管前的配置
+	if _, err := np.Cleanup(ctx, testInterfaces["static"]); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fsys.ReadFile("/etc/netplan/50-cloud-init.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utils.IsGenerated(restored) {
+		t.Errorf("netplan config not restored after cleanup, got:\n%s", restored)
+	}
+}
+
+func TestHandlerRenderInstalledManagers(t *testing.T) {
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/usr/sbin/netplan", "")
+	h := &LinuxNetworkHandler{managers: []INetworkManager{&NetworkManager{}, &Netplan{}, &Ifupdown{}}}
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [{"name": "eth0", "ipAddress": "192.168.1.100/24", "gateway": "192.168.1.1", "mtu": 1500, "nameservers": ["8.8.8.8", "8.8.4.4"]}]}`),
+	}
+
+	files, err := h.Render(testutil.Context(fsys, &testutil.FakeRunner{}), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != "/etc/netplan/99-eth0.yaml" {
+		t.Fatalf("expected only the netplan config, got %+v", files)
+	}
+	testutil.Golden(t, "netplan-static", files[0].Content)
+}
+
+func TestReconcileReloadsActiveManager(t *testing.T) {
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/usr/sbin/netplan", "")
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"systemctl is-active systemd-networkd": {Output: "inactive", ExitCode: 3},
+	}}
+	ctx := testutil.Context(fsys, runner)
+	h := &LinuxNetworkHandler{managers: []INetworkManager{&NetworkManager{}, &Netplan{}, &Ifupdown{}}}
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [{"name": "eth0", "ipAddress": "192.168.1.100/24", "mtu": 1500}]}`),
+	}
+
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"systemctl is-active systemd-networkd",
+		"systemctl is-active NetworkManager",
+		"netplan apply",
+	}
+	if got := runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+
+	// 配置未变化时不重新加载
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != len(want) {
+		t.Errorf("unexpected commands on unchanged config: %q", got[len(want):])
+	}
+}
+
+func TestReconcileRetriesFailedReload(t *testing.T) {
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/usr/sbin/netplan", "")
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"netplan apply": {Output: "Invalid YAML", ExitCode: 1},
+	}}
+	ctx := testutil.Context(fsys, runner)
+	h := &LinuxNetworkHandler{managers: []INetworkManager{&Netplan{}}}
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [{"name": "eth0", "ipAddress": "192.168.1.100/24"}]}`),
+	}
+
+	if _, err := h.Reconcile(ctx, cfg); err == nil {
+		t.Fatal("expected reload failure")
+	}
+
+	// 配置已经写入，重试时仍需重新加载
+	runner.Results = nil
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"systemctl is-active systemd-networkd",
+		"netplan apply",
+		"systemctl is-active systemd-networkd",
+		"netplan apply",
+	}
+	if got := runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+}
+
+func TestRenderBondBridge(t *testing.T) {
+	// 网桥 br0 连接 active-backup bond0，eth1 未单独声明
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [
+			{"name": "br0", "bridge": {"members": ["bond0"], "stp": true, "forwardDelay": 4}, "ipAddress": "192.168.10.2/24", "gateway": "192.168.10.1", "mtu": 1500},
+			{"name": "bond0", "bond": {"members": ["eth0", "eth1"], "primary": "eth0"}, "mtu": 1500},
+			{"name": "eth0", "mtu": 1500}
+		]}`),
+	}
+	managers := map[string]INetworkManager{
+		"netplan":        &Netplan{},
+		"networkmanager": &NetworkManager{},
+		"ifupdown":       &Ifupdown{},
+	}
+
+	for managerName, manager := range managers {
+		t.Run(managerName, func(t *testing.T) {
+			fsys := newRoot(t)
+			testutil.WriteFile(t, fsys, "/usr/sbin/netplan", "")
+			testutil.WriteFile(t, fsys, "/usr/sbin/NetworkManager", "")
+			testutil.WriteFile(t, fsys, "/sbin/ifup", "")
+			h := &LinuxNetworkHandler{managers: []INetworkManager{manager}}
+
+			files, err := h.Render(testutil.Context(fsys, &testutil.FakeRunner{}), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []byte
+			for _, file := range files {
+				got = fmt.Appendf(got, "==> %s <==\n%s\n", file.Path, file.Content)
+			}
+			testutil.Golden(t, managerName+"-bond-bridge", got)
+		})
+	}
+}
+
+func TestConfigureAndCleanup(t *testing.T) {
+	const original = "# managed by the installer\n"
+	tests := []struct {
+		name    string
+		manager INetworkManager
+		path    string
+	}{
+		{"ifupdown", &Ifupdown{}, "/etc/network/interfaces.d/eth0"},
+		{"networkmanager", &NetworkManager{}, "/etc/NetworkManager/system-connections/eth0.nmconnection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := newRoot(t)
+			testutil.WriteFile(t, fsys, tt.path, original)
+			ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+
+			if _, err := tt.manager.Configure(ctx, testInterfaces["static"]); err != nil {
+				t.Fatal(err)
+			}
+			// 备份不能留在按目录加载的配置目录中
+			entries, err := fsys.ReadDir(filepath.Dir(tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("unexpected files in %s: %v", filepath.Dir(tt.path), entries)
+			}
+			if backup, err := fsys.ReadFile(utils.BackupPath(tt.path)); err != nil || string(backup) != original {
+				t.Errorf("got backup %q, err %v", backup, err)
+			}
+
+			// 清理后恢复接管前的配置
+			if changed, err := tt.manager.Cleanup(ctx, testInterfaces["static"]); err != nil || !changed {
+				t.Fatalf("got changed %v, err %v", changed, err)
+			}
+			if restored, err := fsys.ReadFile(tt.path); err != nil || string(restored) != original {
+				t.Errorf("got %q, err %v after cleanup, want the original config", restored, err)
+			}
+
+			// 没有原配置时删除生成的文件
+			if err := fsys.Remove(tt.path); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.manager.Configure(ctx, testInterfaces["static"]); err != nil {
+				t.Fatal(err)
+			}
+			if changed, err := tt.manager.Cleanup(ctx, testInterfaces["static"]); err != nil || !changed {
+				t.Fatalf("got changed %v, err %v", changed, err)
+			}
+			if _, err := fsys.Stat(tt.path); !os.IsNotExist(err) {
+				t.Errorf("generated config not removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestIfupdownSharedConfig(t *testing.T) {
+	const shared = "source /etc/network/interfaces.d/*\n\nauto lo\niface lo inet loopback\n\nauto eth1\niface eth1 inet dhcp\n"
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/etc/network/interfaces", shared)
+	testutil.WriteFile(t, fsys, "/etc/network/interfaces.d/eth0", "auto eth0\niface eth0 inet dhcp\n")
+	testutil.WriteFile(t, fsys, "/sbin/ifup", "")
+	ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+	ifd := &Ifupdown{}
+
+	// 单独定义接口的文件被接管
+	file, err := ifd.Render(ctx, testInterfaces["static"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != "/etc/network/interfaces.d/eth0" {
+		t.Errorf("got path %s, want the existing interfaces.d/eth0", file.Path)
+	}
+
+	// 与 lo 定义在同一文件中的 bond 成员不被接管，文件保持不变
+	h := &LinuxNetworkHandler{managers: []INetworkManager{ifd}}
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [{"name": "bond0", "bond": {"members": ["eth1"]}, "ipAddress": "192.168.10.2/24"}]}`),
+	}
+	if _, err := h.Reconcile(ctx, cfg); err == nil || !strings.Contains(err.Error(), "/etc/network/interfaces") {
+		t.Errorf("got %v, want an error about the shared config", err)
+	}
+	if current, _ := fsys.ReadFile("/etc/network/interfaces"); string(current) != shared {
+		t.Errorf("shared config changed:\n%s", current)
+	}
+	if _, err := fsys.Stat("/etc/network/interfaces.d/bond0"); !os.IsNotExist(err) {
+		t.Errorf("bond configured without its member: %v", err)
+	}
+	if changed, err := ifd.Cleanup(ctx, Interface{Name: "eth1"}); err != nil || changed {
+		t.Errorf("got changed %v, err %v, want the shared config left alone", changed, err)
+	}
+	if current, _ := fsys.ReadFile("/etc/network/interfaces"); string(current) != shared {
+		t.Errorf("shared config changed by cleanup:\n%s", current)
+	}
+}
+
+func TestIfupdownVLANName(t *testing.T) {
+	fsys := newRoot(t)
+	ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+	ifd := &Ifupdown{}
+
+	for _, name := range []string{"eth0.100", "vlan100"} {
+		iface := testInterfaces["vlan"]
+		iface.Name = name
+		if _, err := ifd.Render(ctx, iface); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// 接口名中没有 VLAN ID 时，vlan 软件包无法创建该接口
+	iface := testInterfaces["vlan"]
+	iface.Name = "mgmt"
+	if _, err := ifd.Render(ctx, iface); err == nil || !strings.Contains(err.Error(), "eth0.100") {
+		t.Errorf("got %v, want an error about the interface name", err)
+	}
+}
+
+func TestNetplanSharedConfig(t *testing.T) {
+	const shared = "network:\n  version: 2\n  ethernets:\n    eth0:\n      dhcp4: true\n    eth1:\n      dhcp4: true\n"
+	fsys := newRoot(t)
+	testutil.WriteFile(t, fsys, "/etc/netplan/50-cloud-init.yaml", shared)
+	testutil.WriteFile(t, fsys, "/usr/sbin/netplan", "")
+	ctx := testutil.Context(fsys, &testutil.FakeRunner{})
+	np := &Netplan{}
+
+	// 未定义在其他文件中的接口使用单独的文件
+	file, err := np.Render(ctx, testInterfaces["vlan"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Path != "/etc/netplan/99-eth0.100.yaml" {
+		t.Errorf("got path %s, want a separate file", file.Path)
+	}
+
+	// 与 eth1 定义在同一文件中的 bond 成员不被接管，文件保持不变
+	h := &LinuxNetworkHandler{managers: []INetworkManager{np}}
+	cfg := &config.ResourceConfig{
+		Kind: "NetworkConfiguration",
+		Spec: []byte(`{"interfaces": [{"name": "bond0", "bond": {"members": ["eth0"]}, "ipAddress": "192.168.10.2/24"}]}`),
+	}
+	if _, err := h.Reconcile(ctx, cfg); err == nil || !strings.Contains(err.Error(), "50-cloud-init.yaml") {
+		t.Errorf("got %v, want an error about the shared config", err)
+	}
+	if current, _ := fsys.ReadFile("/etc/netplan/50-cloud-init.yaml"); string(current) != shared {
+		t.Errorf("shared config changed:\n%s", current)
+	}
+	if _, err := fsys.Stat("/etc/netplan/99-bond0.yaml"); !os.IsNotExist(err) {
+		t.Errorf("bond configured without its member: %v", err)
+	}
+	if changed, err := np.Cleanup(ctx, Interface{Name: "eth0"}); err != nil || changed {
+		t.Errorf("got changed %v, err %v, want the shared config left alone", changed, err)
+	}
+	if current, _ := fsys.ReadFile("/etc/netplan/50-cloud-init.yaml"); string(current) != shared {
+		t.Errorf("shared config changed by cleanup:\n%s", current)
+	}
+}
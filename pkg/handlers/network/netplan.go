@@ -1,14 +1,17 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"path/filepath"
-	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/controller"
 	"go.xbrother.com/nix-operator/pkg/utils"
 	"gopkg.in/yaml.v3"
 )
@@ -22,28 +25,73 @@ type NetplanConfig struct {
 
 type NetplanNetwork struct {
 	Version   int                         `yaml:"version"`
-	Ethernets map[string]NetplanInterface `yaml:"ethernets"`
+	Ethernets map[string]NetplanInterface `yaml:"ethernets,omitempty"`
+	VLANs     map[string]NetplanInterface `yaml:"vlans,omitempty"`
+	Bonds     map[string]NetplanInterface `yaml:"bonds,omitempty"`
+	Bridges   map[string]NetplanInterface `yaml:"bridges,omitempty"`
 }
 
 type NetplanInterface struct {
-	MTU         int                 `yaml:"mtu,omitempty"`
-	Addresses   []string            `yaml:"addresses,omitempty"`
-	Gateway4    string              `yaml:"gateway4,omitempty"`
-	Gateway6    string              `yaml:"gateway6,omitempty"`
-	Nameservers *NetplanNameservers `yaml:"nameservers,omitempty"`
+	ID             int                   `yaml:"id,omitempty"`
+	Link           string                `yaml:"link,omitempty"`
+	Interfaces     []string              `yaml:"interfaces,omitempty"`
+	Parameters     *NetplanParameters    `yaml:"parameters,omitempty"`
+	MTU            int                   `yaml:"mtu,omitempty"`
+	DHCP4          bool                  `yaml:"dhcp4,omitempty"`
+	DHCP6          bool                  `yaml:"dhcp6,omitempty"`
+	AcceptRA       bool                  `yaml:"accept-ra,omitempty"`
+	DHCPIdentifier string                `yaml:"dhcp-identifier,omitempty"`
+	DHCP4Overrides *NetplanDHCPOverrides `yaml:"dhcp4-overrides,omitempty"`
+	DHCP6Overrides *NetplanDHCPOverrides `yaml:"dhcp6-overrides,omitempty"`
+	Addresses      []string              `yaml:"addresses,omitempty"`
+	Gateway4       string                `yaml:"gateway4,omitempty"`
+	Gateway6       string                `yaml:"gateway6,omitempty"`
+	Routes         []NetplanRoute        `yaml:"routes,omitempty"`
+	Nameservers    *NetplanNameservers   `yaml:"nameservers,omitempty"`
 }
 
 type NetplanNameservers struct {
 	Addresses []string `yaml:"addresses"`
 }
 
+// NetplanParameters 是 bond 和网桥的参数
+type NetplanParameters struct {
+	Mode               string `yaml:"mode,omitempty"`
+	MIIMonitorInterval int    `yaml:"mii-monitor-interval,omitempty"`
+	Primary            string `yaml:"primary,omitempty"`
+	// netplan 默认启用生成树，网桥总是写出该参数
+	STP          *bool `yaml:"stp,omitempty"`
+	ForwardDelay int   `yaml:"forward-delay,omitempty"`
+}
+
+type NetplanRoute struct {
+	To     string `yaml:"to"`
+	Via    string `yaml:"via,omitempty"`
+	Metric int    `yaml:"metric,omitempty"`
+	Table  int    `yaml:"table,omitempty"`
+	OnLink bool   `yaml:"on-link,omitempty"`
+}
+
+type NetplanDHCPOverrides struct {
+	UseDNS       *bool  `yaml:"use-dns,omitempty"`
+	SendHostname *bool  `yaml:"send-hostname,omitempty"`
+	Hostname     string `yaml:"hostname,omitempty"`
+	RouteMetric  int    `yaml:"route-metric,omitempty"`
+}
+
+func (np *Netplan) Name() string {
+	return "netplan"
+}
+
 func (np *Netplan) IsInstall(ctx context.Context) bool {
-	_, err := os.Stat("/usr/sbin/netplan")
+	_, err := utils.FSFromContext(ctx).Stat("/usr/sbin/netplan")
 	return err == nil
 }
 
-func (np *Netplan) findConfig(iface config.Interface) (string, error) {
-	files, err := os.ReadDir("/etc/netplan")
+// findConfig 返回定义接口的配置文件，未定义时为 99-<name>.yaml
+// 接口与其他接口定义在同一个文件中（如 50-cloud-init.yaml）时拒绝接管，需要先将其移到单独的文件
+func (np *Netplan) findConfig(fsys utils.FS, iface Interface) (string, error) {
+	files, err := fsys.ReadDir("/etc/netplan")
 	if err != nil {
 		return "", fmt.Errorf("failed to read netplan directory: %v", err)
 	}
@@ -54,57 +102,122 @@ func (np *Netplan) findConfig(iface config.Interface) (string, error) {
 		}
 
 		path := filepath.Join("/etc/netplan", file.Name())
-		data, err := os.ReadFile(path)
+		data, err := fsys.ReadFile(path)
 		if err != nil {
 			continue
 		}
 
-		var config map[string]any
-		if err := yaml.Unmarshal(data, &config); err != nil {
+		devices := netplanDevices(data)
+		if !slices.Contains(devices[netplanSection(iface)], iface.Name) {
 			continue
 		}
-
-		network, ok := config["network"].(map[string]any)
-		if !ok {
-			continue
+		for _, names := range devices {
+			for _, name := range names {
+				if name != iface.Name {
+					return "", fmt.Errorf("%w: %s is also configured in %s, move it to /etc/netplan/99-%s.yaml",
+						errSharedConfig, iface.Name, path, iface.Name)
+				}
+			}
 		}
+		return path, nil
+	}
+
+	return fmt.Sprintf("/etc/netplan/99-%s.yaml", iface.Name), nil
+}
+
+// netplanDevices 返回 netplan 配置中按设备类型（ethernets、vlans、bonds、bridges 等）分组的接口名称
+func netplanDevices(data []byte) map[string][]string {
+	var config struct {
+		Network map[string]any `yaml:"network"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil
+	}
 
-		ethernets, ok := network["ethernets"].(map[string]any)
+	devices := make(map[string][]string)
+	for section, value := range config.Network {
+		// version、renderer 等不是设备定义
+		definitions, ok := value.(map[string]any)
 		if !ok {
 			continue
 		}
-
-		if _, ok := ethernets[iface.Name]; ok {
-			return path, nil
+		for name := range definitions {
+			devices[section] = append(devices[section], name)
 		}
 	}
-
-	return fmt.Sprintf("/etc/netplan/99-%s.yaml", iface.Name), nil
+	return devices
 }
 
-func (np *Netplan) buildInterfaceConfig(iface config.Interface) NetplanInterface {
+func (np *Netplan) buildInterfaceConfig(iface Interface, legacyGateway bool) NetplanInterface {
 	ifaceConfig := NetplanInterface{
 		MTU: iface.MTU,
 	}
-
-	// 配置地址
-	var addresses []string
-	if iface.IPAddress != "" {
-		addresses = append(addresses, iface.IPAddress)
+	if iface.VLAN != nil {
+		ifaceConfig.ID = iface.VLAN.ID
+		ifaceConfig.Link = iface.VLAN.Link
+	}
+	if iface.Bond != nil {
+		ifaceConfig.Interfaces = iface.Bond.Members
+		ifaceConfig.Parameters = &NetplanParameters{
+			Mode:               iface.Bond.Mode,
+			MIIMonitorInterval: iface.Bond.MIIMon,
+			Primary:            iface.Bond.Primary,
+		}
 	}
-	if iface.IPv6Address != "" {
-		addresses = append(addresses, iface.IPv6Address)
+	if iface.Bridge != nil {
+		stp := iface.Bridge.STP
+		ifaceConfig.Interfaces = iface.Bridge.Members
+		ifaceConfig.Parameters = &NetplanParameters{STP: &stp, ForwardDelay: iface.Bridge.ForwardDelay}
 	}
-	if len(addresses) > 0 {
+
+	// 配置地址
+	if addresses := append(iface.IPv4Addresses(), iface.IPv6Addresses()...); len(addresses) > 0 {
 		ifaceConfig.Addresses = addresses
 	}
 
-	// 配置网关
-	if iface.Gateway != "" {
-		ifaceConfig.Gateway4 = iface.Gateway
+	// 配置动态地址，networkd 要求 DHCPv4 和 DHCPv6 的覆盖项一致
+	ifaceConfig.DHCP4 = iface.IPv4Mode() == AddressDHCP
+	ifaceConfig.DHCP6 = iface.DHCPv6()
+	ifaceConfig.AcceptRA = iface.AcceptRA()
+	if iface.DHCP != nil && (ifaceConfig.DHCP4 || ifaceConfig.DHCP6) {
+		overrides := &NetplanDHCPOverrides{
+			SendHostname: iface.DHCP.SendHostname,
+			Hostname:     iface.DHCP.Hostname,
+			RouteMetric:  iface.DHCP.RouteMetric,
+		}
+		if iface.DHCP.IgnoreDNS {
+			overrides.UseDNS = new(bool)
+		}
+		if ifaceConfig.DHCP4 {
+			ifaceConfig.DHCPIdentifier = iface.DHCP.ClientID
+			ifaceConfig.DHCP4Overrides = overrides
+		}
+		if ifaceConfig.DHCP6 {
+			ifaceConfig.DHCP6Overrides = overrides
+		}
 	}
-	if iface.IPv6Gateway != "" {
+
+	// 配置网关，旧版 netplan 不支持 to: default
+	if legacyGateway {
+		ifaceConfig.Gateway4 = iface.Gateway
 		ifaceConfig.Gateway6 = iface.IPv6Gateway
+	} else {
+		for _, gateway := range []string{iface.Gateway, iface.IPv6Gateway} {
+			if gateway != "" {
+				ifaceConfig.Routes = append(ifaceConfig.Routes, NetplanRoute{To: "default", Via: gateway})
+			}
+		}
+	}
+
+	// 配置静态路由
+	for _, route := range append(iface.IPv4Routes(), iface.IPv6Routes()...) {
+		ifaceConfig.Routes = append(ifaceConfig.Routes, NetplanRoute{
+			To:     route.To,
+			Via:    route.Via,
+			Metric: route.Metric,
+			Table:  route.Table,
+			OnLink: route.OnLink,
+		})
 	}
 
 	// 配置DNS nameservers
@@ -117,40 +230,161 @@ func (np *Netplan) buildInterfaceConfig(iface config.Interface) NetplanInterface
 	return ifaceConfig
 }
 
-func (np *Netplan) Configure(ctx context.Context, iface config.Interface) error {
-	configPath, err := np.findConfig(iface)
-	if err != nil {
-		return err
+func (np *Netplan) buildConfig(iface Interface, legacyGateway bool) NetplanConfig {
+	devices := map[string]NetplanInterface{
+		iface.Name: np.buildInterfaceConfig(iface, legacyGateway),
 	}
+	network := NetplanNetwork{Version: 2}
+	switch iface.Type() {
+	case TypeVLAN:
+		network.VLANs = devices
+	case TypeBond:
+		network.Bonds = devices
+	case TypeBridge:
+		network.Bridges = devices
+	default:
+		network.Ethernets = devices
+	}
+	return NetplanConfig{Network: network}
+}
 
-	// 构建配置结构
-	desired := NetplanConfig{
-		Network: NetplanNetwork{
-			Version: 2,
-			Ethernets: map[string]NetplanInterface{
-				iface.Name: np.buildInterfaceConfig(iface),
-			},
-		},
+// netplanSection 返回接口在 netplan 配置中所属的设备类型
+func netplanSection(iface Interface) string {
+	switch iface.Type() {
+	case TypeVLAN:
+		return "vlans"
+	case TypeBond:
+		return "bonds"
+	case TypeBridge:
+		return "bridges"
 	}
+	return "ethernets"
+}
 
-	// 读取现有配置进行比较
-	var current NetplanConfig
-	if data, err := os.ReadFile(configPath); err == nil {
-		if err := yaml.Unmarshal(data, &current); err == nil && reflect.DeepEqual(current, desired) {
-			return nil // 配置相同，无需更新
-		}
+func (np *Netplan) Render(ctx context.Context, iface Interface) (controller.RenderedFile, error) {
+	fsys := utils.FSFromContext(ctx)
+	configPath, err := np.findConfig(fsys, iface)
+	if err != nil {
+		return controller.RenderedFile{}, err
 	}
 
-	// 序列化并写入新配置
-	data, err := yaml.Marshal(desired)
+	// 序列化配置
+	data, err := yaml.Marshal(np.buildConfig(iface, legacyGateway(fsys)))
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %v", err)
+		return controller.RenderedFile{}, fmt.Errorf("failed to marshal config: %v", err)
 	}
 
 	// 添加注释头
 	configWithHeader := append([]byte(config.CommentHeader), data...)
 
-	return utils.AtomicWriteFile(configWithHeader, configPath, 0644)
+	return controller.RenderedFile{Path: configPath, Content: configWithHeader, Mode: 0644}, nil
+}
+
+func (np *Netplan) Configure(ctx context.Context, iface Interface) (bool, error) {
+	file, err := np.Render(ctx, iface)
+	if err != nil {
+		return false, err
+	}
+
+	fsys := utils.FSFromContext(ctx)
+
+	// 与漂移检查一样按内容比较，仅格式或顺序不同的文件也被重写
+	current, err := fsys.ReadFile(file.Path)
+	if err == nil && bytes.Equal(current, file.Content) {
+		return false, nil // 配置相同，无需更新
+	}
+
+	// 首次接管前备份原文件
+	if err := utils.BackupFile(fsys, file.Path); err != nil {
+		return false, err
+	}
+
+	if err := fsys.WriteFile(file.Path, file.Content, file.Mode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (np *Netplan) Cleanup(ctx context.Context, iface Interface) (bool, error) {
+	fsys := utils.FSFromContext(ctx)
+	configPath, err := np.findConfig(fsys, iface)
+	if errors.Is(err, errSharedConfig) {
+		return false, nil // 从未接管过共享的文件
+	}
+	if err != nil {
+		return false, err
+	}
+	return utils.RevertFile(fsys, configPath)
+}
+
+// netplanDefaultRoute 是支持 routes 中 to: default 并弃用 gateway4/gateway6 的 netplan 版本
+var netplanDefaultRoute = [2]int{0, 103}
+
+// legacyGateway 返回已安装的 netplan 是否早于 0.103，需要使用 gateway4/gateway6
+// 版本从 dpkg 的安装记录读取，无法确定版本时按新版本处理
+func legacyGateway(fsys utils.FS) bool {
+	data, err := fsys.ReadFile("/var/lib/dpkg/status")
+	if err != nil {
+		return false
+	}
+	version, ok := packageVersion(string(data), "netplan.io")
+	if !ok {
+		return false
+	}
+	major, minor, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	return major < netplanDefaultRoute[0] || major == netplanDefaultRoute[0] && minor < netplanDefaultRoute[1]
+}
+
+// packageVersion 在 dpkg 状态文件中查找已安装软件包的版本
+func packageVersion(status, name string) (string, bool) {
+	for _, stanza := range strings.Split(status, "\n\n") {
+		var pkg, version string
+		installed := false
+		for _, line := range strings.Split(stanza, "\n") {
+			key, value, _ := strings.Cut(line, ": ")
+			switch key {
+			case "Package":
+				pkg = value
+			case "Version":
+				version = value
+			case "Status":
+				installed = strings.HasSuffix(value, " installed")
+			}
+		}
+		if pkg == name && installed && version != "" {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// parseVersion 解析 Debian 版本号（如 1:0.106.1-7ubuntu0.22.04.2）中上游版本的主、次版本号
+func parseVersion(version string) (major, minor int, ok bool) {
+	if _, upstream, found := strings.Cut(version, ":"); found {
+		version = upstream
+	}
+	fields := strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '-' || r == '~' || r == '+'
+	})
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+func (np *Netplan) ReloadCommand() []string {
+	return []string{"netplan", "apply"}
 }
 
 func (np *Netplan) ReloadIfy(ctx context.Context) error {
@@ -160,8 +394,8 @@ func (np *Netplan) ReloadIfy(ctx context.Context) error {
 		return nil
 	}
 
-	cmd := exec.CommandContext(ctx, "netplan", "apply")
-	if output, err := cmd.CombinedOutput(); err != nil {
+	args := np.ReloadCommand()
+	if output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...); err != nil {
 		return fmt.Errorf("failed to apply netplan: %v, output: %s", err, output)
 	}
 	return nil
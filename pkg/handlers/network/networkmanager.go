@@ -5,12 +5,13 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
-	"os"
-	"os/exec"
+	"net/netip"
+	"strconv"
 	"strings"
 	"text/template"
 
 	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/controller"
 	"go.xbrother.com/nix-operator/pkg/utils"
 )
 
@@ -19,28 +20,40 @@ type NetworkManager struct{}
 //go:embed nmconnection.tpl
 var nmConnectionTemplate string
 
+func (nm *NetworkManager) Name() string {
+	return "NetworkManager"
+}
+
 func (nm *NetworkManager) IsInstall(ctx context.Context) bool {
-	_, err := os.Stat("/usr/sbin/NetworkManager")
+	_, err := utils.FSFromContext(ctx).Stat("/usr/sbin/NetworkManager")
 	return err == nil
 }
 
-func (nm *NetworkManager) Configure(ctx context.Context, iface config.Interface) error {
-	configPath := fmt.Sprintf("/etc/NetworkManager/system-connections/%s.nmconnection", iface.Name)
+func (nm *NetworkManager) configPath(iface Interface) string {
+	return fmt.Sprintf("/etc/NetworkManager/system-connections/%s.nmconnection", iface.Name)
+}
+
+func (nm *NetworkManager) Render(ctx context.Context, iface Interface) (controller.RenderedFile, error) {
+	configPath := nm.configPath(iface)
 
 	// 创建模板并添加自定义函数
 	tmpl := template.New("nmconnection").Funcs(template.FuncMap{
-		"join": strings.Join,
+		"join":           strings.Join,
+		"filter":         filterFamily,
+		"inc":            func(i int) int { return i + 1 },
+		"nmRoute":        nmRoute,
+		"nmRouteOptions": nmRouteOptions,
 	})
 
 	tmpl, err := tmpl.Parse(nmConnectionTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %v", err)
+		return controller.RenderedFile{}, fmt.Errorf("failed to parse template: %v", err)
 	}
 
 	// 准备模板数据
 	data := struct {
 		CommentHeader string
-		Interface     config.Interface
+		Interface     Interface
 	}{
 		CommentHeader: config.CommentHeader,
 		Interface:     iface,
@@ -49,28 +62,96 @@ func (nm *NetworkManager) Configure(ctx context.Context, iface config.Interface)
 	// 渲染模板
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return fmt.Errorf("failed to execute template: %v", err)
+		return controller.RenderedFile{}, fmt.Errorf("failed to execute template: %v", err)
+	}
+
+	return controller.RenderedFile{Path: configPath, Content: buf.Bytes(), Mode: 0600}, nil
+}
+
+func (nm *NetworkManager) Configure(ctx context.Context, iface Interface) (bool, error) {
+	file, err := nm.Render(ctx, iface)
+	if err != nil {
+		return false, err
 	}
 
-	desired := buf.String()
+	fsys := utils.FSFromContext(ctx)
 
 	// 读取现有配置
-	current, err := os.ReadFile(configPath)
-	if err == nil && string(current) == desired {
-		return nil // 配置相同，无需更新
+	current, err := fsys.ReadFile(file.Path)
+	if err == nil && bytes.Equal(current, file.Content) {
+		return false, nil // 配置相同，无需更新
+	}
+
+	// 首次接管前备份原文件
+	if err := utils.BackupFile(fsys, file.Path); err != nil {
+		return false, err
 	}
 
 	// 写入新配置
-	return utils.AtomicWriteFile([]byte(desired), configPath, 0600)
+	if err := fsys.WriteFile(file.Path, file.Content, file.Mode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (nm *NetworkManager) Cleanup(ctx context.Context, iface Interface) (bool, error) {
+	return utils.RevertFile(utils.FSFromContext(ctx), nm.configPath(iface))
+}
+
+func (nm *NetworkManager) ReloadCommand() []string {
+	return []string{"nmcli", "connection", "reload"}
 }
 
 func (nm *NetworkManager) ReloadIfy(ctx context.Context) error {
 	if !isServiceActive(ctx, "NetworkManager") {
 		return nil
 	}
-	cmd := exec.CommandContext(ctx, "nmcli", "connection", "reload")
-	if output, err := cmd.CombinedOutput(); err != nil {
+	args := nm.ReloadCommand()
+	if output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...); err != nil {
 		return fmt.Errorf("failed to reload NetworkManager: %v, output: %s", err, output)
 	}
 	return nil
 }
+
+// filterFamily 返回 addrs 中属于 IPv4 或 IPv6 的地址，NetworkManager 按协议分别配置 DNS
+func filterFamily(ipv6 bool, addrs []string) []string {
+	var filtered []string
+	for _, addr := range addrs {
+		if ip, err := netip.ParseAddr(addr); err == nil && ip.Is4() != ipv6 {
+			filtered = append(filtered, addr)
+		}
+	}
+	return filtered
+}
+
+// nmRoute 返回 keyfile 中 routeN 的值：目标网段[,下一跳[,优先级]]，没有下一跳时以未指定地址占位
+func nmRoute(route Route) string {
+	value := route.To
+	if route.Via == "" && route.Metric == 0 {
+		return value
+	}
+	via := route.Via
+	if via == "" {
+		via = "0.0.0.0"
+		if strings.Contains(route.To, ":") {
+			via = "::"
+		}
+	}
+	value += "," + via
+	if route.Metric != 0 {
+		value += "," + strconv.Itoa(route.Metric)
+	}
+	return value
+}
+
+// nmRouteOptions 返回 keyfile 中 routeN_options 的值
+func nmRouteOptions(route Route) string {
+	var options []string
+	if route.OnLink {
+		options = append(options, "onlink=true")
+	}
+	if route.Table != 0 {
+		options = append(options, "table="+strconv.Itoa(route.Table))
+	}
+	return strings.Join(options, ",")
+}
@@ -1,29 +1,105 @@
 {{.CommentHeader}}[connection]
 id={{.Interface.Name}}
-type=ethernet
+type={{.Interface.Type}}
 interface-name={{.Interface.Name}}
+{{- with .Interface.Controller}}
+master={{.}}
+slave-type={{$.Interface.ControllerType}}
+{{- end}}
+{{- with .Interface.VLAN}}
+
+[vlan]
+id={{.ID}}
+parent={{.Link}}
+{{- end}}
+{{- with .Interface.Bond}}
+
+[bond]
+mode={{.Mode}}
+miimon={{.MIIMon}}
+{{- if .Primary}}
+primary={{.Primary}}
+{{- end}}
+{{- end}}
+{{- with .Interface.Bridge}}
+
+[bridge]
+stp={{.STP}}
+{{- if .ForwardDelay}}
+forward-delay={{.ForwardDelay}}
+{{- end}}
+{{- end}}
+{{- if not .Interface.Controller}}
 
 [ipv4]
-{{- if .Interface.IPAddress}}
-address1={{.Interface.IPAddress}}
+{{- $v4 := .Interface.IPv4Mode}}
+{{- range $i, $address := .Interface.IPv4Addresses}}
+address{{inc $i}}={{$address}}
+{{- end}}
+{{- if eq $v4 "static"}}
 method=manual
 {{- if .Interface.Gateway}}
 gateway={{.Interface.Gateway}}
 {{- end}}
+{{- else if eq $v4 "dhcp"}}
+method=auto
+{{- with .Interface.DHCP}}
+{{- if .ClientID}}
+dhcp-client-id={{.ClientID}}
+{{- end}}
+{{- template "dhcp" .}}
+{{- end}}
 {{- else}}
 method=disabled
 {{- end}}
-{{- if .Interface.Nameservers}}
-dns={{join .Interface.Nameservers ";"}}
+{{- template "routes" .Interface.IPv4Routes}}
+{{- with filter false .Interface.Nameservers}}
+dns={{join . ";"}}
 {{- end}}
 
 [ipv6]
-{{- if .Interface.IPv6Address}}
-address1={{.Interface.IPv6Address}}
+{{- $v6 := .Interface.IPv6Mode}}
+{{- range $i, $address := .Interface.IPv6Addresses}}
+address{{inc $i}}={{$address}}
+{{- end}}
+{{- if eq $v6 "static"}}
 method=manual
 {{- if .Interface.IPv6Gateway}}
 gateway={{.Interface.IPv6Gateway}}
 {{- end}}
+{{- else if eq $v6 "dhcp"}}
+method=dhcp
+{{- with .Interface.DHCP}}{{template "dhcp" .}}{{end}}
+{{- else if or (eq $v6 "slaac") (eq $v6 "auto")}}
+method=auto
+{{- with .Interface.DHCP}}{{template "dhcp" .}}{{end}}
 {{- else}}
 method=disabled
+{{- end}}
+{{- template "routes" .Interface.IPv6Routes}}
+{{- with filter true .Interface.Nameservers}}
+dns={{join . ";"}}
+{{- end}}
+{{- end}}
+{{- define "dhcp"}}
+{{- if .SendHostname}}
+dhcp-send-hostname={{.SendHostname}}
+{{- end}}
+{{- if .Hostname}}
+dhcp-hostname={{.Hostname}}
+{{- end}}
+{{- if .RouteMetric}}
+route-metric={{.RouteMetric}}
+{{- end}}
+{{- if .IgnoreDNS}}
+ignore-auto-dns=true
+{{- end}}
+{{- end}}
+{{- define "routes"}}
+{{- range $i, $route := .}}
+route{{inc $i}}={{nmRoute $route}}
+{{- with nmRouteOptions $route}}
+route{{inc $i}}_options={{.}}
+{{- end}}
+{{- end}}
 {{- end}}
\ No newline at end of file
@@ -2,19 +2,28 @@ package network
 
 import (
 	"context"
-	"os/exec"
 
-	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/utils"
 )
 
 type INetworkManager interface {
+	// Name 返回网络管理器的名称，用于日志
+	Name() string
 	IsInstall(ctx context.Context) bool
-	Configure(ctx context.Context, iface config.Interface) error
+	// Render 生成接口的期望配置文件，不修改系统
+	Render(ctx context.Context, iface Interface) (controller.RenderedFile, error)
+	// Configure 写入接口配置，返回配置是否发生了变化
+	Configure(ctx context.Context, iface Interface) (bool, error)
+	// Cleanup 撤销为接口生成的配置，返回配置是否发生了变化
+	Cleanup(ctx context.Context, iface Interface) (bool, error)
+	// ReloadCommand 返回使配置生效的命令
+	ReloadCommand() []string
 	ReloadIfy(ctx context.Context) error
 }
 
 // 检查服务是否启动
 func isServiceActive(ctx context.Context, service string) bool {
-	cmd := exec.CommandContext(ctx, "systemctl", "is-active", service)
-	return cmd.Run() == nil
+	_, err := utils.RunnerFromContext(ctx).Run(ctx, "systemctl", "is-active", service)
+	return err == nil
 }
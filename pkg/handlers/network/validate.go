@@ -0,0 +1,244 @@
+package network
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// interfaceName 匹配 Linux 网络接口名，长度不超过 15 个字符
+var interfaceName = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,15}$`)
+
+// Validate 校验接口名称、VLAN、bond、网桥、地址获取方式、地址、网关、路由、DNS 服务器和 DHCP 配置
+func (c *Config) Validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	for i, iface := range c.Interfaces {
+		errs = append(errs, iface.validate(path.Child("interfaces").Index(i))...)
+	}
+	errs = append(errs, c.validateMembers(path)...)
+	return errs
+}
+
+// validateMembers 校验每个接口最多属于一个 bond 或网桥，单独声明的成员接口不能配置地址和路由
+func (c *Config) validateMembers(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	controllers := make(map[string]string)
+	for i, iface := range c.Interfaces {
+		membersPath := path.Child("interfaces").Index(i).Child("bridge").Child("members")
+		if iface.Bond != nil {
+			membersPath = path.Child("interfaces").Index(i).Child("bond").Child("members")
+		}
+		for j, member := range iface.Members() {
+			if member == iface.Name {
+				continue
+			}
+			if controller, ok := controllers[member]; ok && controller != iface.Name {
+				errs = append(errs, validation.Invalid(membersPath.Index(j), member, fmt.Sprintf("is already a member of %s", controller)))
+				continue
+			}
+			controllers[member] = iface.Name
+		}
+	}
+
+	for i, iface := range c.Interfaces {
+		controller, ok := controllers[iface.Name]
+		if !ok {
+			continue
+		}
+		if iface.IPv4Mode() != AddressDisabled || iface.IPv6Mode() != AddressDisabled || len(iface.Routes) > 0 {
+			errs = append(errs, validation.Invalid(path.Child("interfaces").Index(i).Child("name"), iface.Name,
+				fmt.Sprintf("is a member of %s and must not have addresses or routes", controller)))
+		}
+	}
+	return errs
+}
+
+func (iface *Interface) validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	switch {
+	case iface.Name == "":
+		errs = append(errs, validation.Required(path.Child("name")))
+	case !interfaceName.MatchString(iface.Name):
+		errs = append(errs, validation.Invalid(path.Child("name"), iface.Name, "must be a network interface name of at most 15 characters"))
+	}
+
+	if iface.VLAN != nil {
+		vlanPath := path.Child("vlan")
+		switch {
+		case iface.VLAN.Link == "":
+			errs = append(errs, validation.Required(vlanPath.Child("link")))
+		case !interfaceName.MatchString(iface.VLAN.Link):
+			errs = append(errs, validation.Invalid(vlanPath.Child("link"), iface.VLAN.Link, "must be a network interface name of at most 15 characters"))
+		case iface.VLAN.Link == iface.Name:
+			errs = append(errs, validation.Invalid(vlanPath.Child("link"), iface.VLAN.Link, "must differ from the interface name"))
+		}
+		errs = append(errs, validation.ValidateRange(vlanPath.Child("id"), iface.VLAN.ID, 1, 4094)...)
+	}
+
+	if iface.VLAN != nil && (iface.Bond != nil || iface.Bridge != nil) || iface.Bond != nil && iface.Bridge != nil {
+		errs = append(errs, validation.Invalid(path.Child("name"), iface.Name, "can only be one of vlan, bond or bridge"))
+	}
+	if iface.Bond != nil {
+		bondPath := path.Child("bond")
+		if iface.Bond.Mode != "" {
+			errs = append(errs, validation.ValidateOneOf(bondPath.Child("mode"), iface.Bond.Mode,
+				"balance-rr", "active-backup", "balance-xor", "broadcast", "802.3ad", "balance-tlb", "balance-alb")...)
+		}
+		errs = append(errs, validation.ValidateNonNegative(bondPath.Child("miimon"), iface.Bond.MIIMon)...)
+		if len(iface.Bond.Members) == 0 {
+			errs = append(errs, validation.Required(bondPath.Child("members")))
+		}
+		errs = append(errs, iface.validateMemberNames(bondPath.Child("members"), iface.Bond.Members)...)
+		if iface.Bond.Primary != "" && !slices.Contains(iface.Bond.Members, iface.Bond.Primary) {
+			errs = append(errs, validation.Invalid(bondPath.Child("primary"), iface.Bond.Primary, "must be one of members"))
+		}
+	}
+	if iface.Bridge != nil {
+		bridgePath := path.Child("bridge")
+		errs = append(errs, iface.validateMemberNames(bridgePath.Child("members"), iface.Bridge.Members)...)
+		errs = append(errs, validation.ValidateRange(bridgePath.Child("forwardDelay"), iface.Bridge.ForwardDelay, 0, 30)...)
+	}
+
+	selectorPath := path.Child("nodeSelector")
+	if iface.NodeSelector.MACAddress != "" {
+		errs = append(errs, validation.ValidateMAC(selectorPath.Child("macAddress"), iface.NodeSelector.MACAddress)...)
+	}
+	if iface.NodeSelector.Hostname != "" {
+		errs = append(errs, validation.ValidateHostname(selectorPath.Child("hostname"), iface.NodeSelector.Hostname)...)
+	}
+
+	if iface.IPv4Method != "" {
+		errs = append(errs, validation.ValidateOneOf(path.Child("ipv4Method"), iface.IPv4Method, AddressStatic, AddressDHCP, AddressDisabled)...)
+	}
+	if iface.IPv6Method != "" {
+		errs = append(errs, validation.ValidateOneOf(path.Child("ipv6Method"), iface.IPv6Method, AddressStatic, AddressSLAAC, AddressDHCP, AddressAuto, AddressDisabled)...)
+	}
+	errs = append(errs, validateStatic(path, "ipv4Method", iface.IPv4Method, "ipAddress", iface.IPAddress, "gateway", iface.Gateway, len(iface.IPv4Addresses()) > 0)...)
+	errs = append(errs, validateStatic(path, "ipv6Method", iface.IPv6Method, "ipv6Address", iface.IPv6Address, "ipv6Gateway", iface.IPv6Gateway, len(iface.IPv6Addresses()) > 0)...)
+
+	if iface.IPAddress != "" {
+		errs = append(errs, validation.ValidateIPv4CIDR(path.Child("ipAddress"), iface.IPAddress)...)
+	}
+	if iface.IPv6Address != "" {
+		errs = append(errs, validation.ValidateIPv6CIDR(path.Child("ipv6Address"), iface.IPv6Address)...)
+	}
+	if iface.Gateway != "" {
+		errs = append(errs, validation.ValidateIPv4(path.Child("gateway"), iface.Gateway)...)
+	}
+	if iface.IPv6Gateway != "" {
+		errs = append(errs, validation.ValidateIPv6(path.Child("ipv6Gateway"), iface.IPv6Gateway)...)
+	}
+	for i, address := range iface.Addresses {
+		addressPath := path.Child("addresses").Index(i)
+		ipv6 := strings.Contains(address, ":")
+		if ipv6 {
+			errs = append(errs, validation.ValidateIPv6CIDR(addressPath, address)...)
+		} else {
+			errs = append(errs, validation.ValidateIPv4CIDR(addressPath, address)...)
+		}
+		errs = append(errs, iface.validateFamily(addressPath, address, ipv6)...)
+	}
+	for i, route := range iface.Routes {
+		errs = append(errs, iface.validateRoute(path.Child("routes").Index(i), route)...)
+	}
+	if iface.MTU != 0 {
+		errs = append(errs, validation.ValidateRange(path.Child("mtu"), iface.MTU, 68, 65535)...)
+	}
+	if iface.MACAddress != "" {
+		errs = append(errs, validation.ValidateMAC(path.Child("macAddress"), iface.MACAddress)...)
+	}
+	for i, nameserver := range iface.Nameservers {
+		errs = append(errs, validation.ValidateIP(path.Child("nameservers").Index(i), nameserver)...)
+	}
+
+	if iface.DHCP != nil {
+		dhcpPath := path.Child("dhcp")
+		if iface.DHCP.ClientID != "" {
+			errs = append(errs, validation.ValidateOneOf(dhcpPath.Child("clientId"), iface.DHCP.ClientID, "mac", "duid")...)
+		}
+		if iface.DHCP.Hostname != "" {
+			errs = append(errs, validation.ValidateHostname(dhcpPath.Child("hostname"), iface.DHCP.Hostname)...)
+		}
+		errs = append(errs, validation.ValidateNonNegative(dhcpPath.Child("routeMetric"), iface.DHCP.RouteMetric)...)
+	}
+	return errs
+}
+
+// validateStatic 校验静态地址方式必须配置地址，其他方式不能配置主地址和网关
+func validateStatic(path *validation.Path, methodField, method, addressField, address, gatewayField, gateway string, hasAddress bool) validation.ErrorList {
+	var errs validation.ErrorList
+	switch {
+	case method == AddressStatic && !hasAddress:
+		errs = append(errs, validation.Required(path.Child(addressField)))
+	case method != "" && method != AddressStatic:
+		detail := fmt.Sprintf("must be empty unless %s is %s", methodField, AddressStatic)
+		if address != "" {
+			errs = append(errs, validation.Invalid(path.Child(addressField), address, detail))
+		}
+		if gateway != "" {
+			errs = append(errs, validation.Invalid(path.Child(gatewayField), gateway, detail))
+		}
+	}
+	return errs
+}
+
+func (iface *Interface) validateRoute(path *validation.Path, route Route) validation.ErrorList {
+	var errs validation.ErrorList
+	ipv6 := strings.Contains(route.To, ":")
+	switch {
+	case route.To == "":
+		errs = append(errs, validation.Required(path.Child("to")))
+	case ipv6:
+		errs = append(errs, validation.ValidateIPv6CIDR(path.Child("to"), route.To)...)
+	default:
+		errs = append(errs, validation.ValidateIPv4CIDR(path.Child("to"), route.To)...)
+	}
+	if route.To != "" {
+		errs = append(errs, iface.validateFamily(path.Child("to"), route.To, ipv6)...)
+	}
+
+	switch {
+	case route.Via == "":
+	case ipv6:
+		errs = append(errs, validation.ValidateIPv6(path.Child("via"), route.Via)...)
+	default:
+		errs = append(errs, validation.ValidateIPv4(path.Child("via"), route.Via)...)
+	}
+	if route.OnLink && route.Via == "" {
+		errs = append(errs, validation.Invalid(path.Child("onLink"), route.OnLink, "requires via"))
+	}
+	errs = append(errs, validation.ValidateNonNegative(path.Child("metric"), route.Metric)...)
+	errs = append(errs, validation.ValidateNonNegative(path.Child("table"), route.Table)...)
+	return errs
+}
+
+// validateFamily 校验附加地址和路由所属的协议未被禁用
+func (iface *Interface) validateFamily(path *validation.Path, value string, ipv6 bool) validation.ErrorList {
+	methodField, mode := "ipv4Method", iface.IPv4Mode()
+	if ipv6 {
+		methodField, mode = "ipv6Method", iface.IPv6Mode()
+	}
+	if mode == AddressDisabled {
+		return validation.ErrorList{validation.Invalid(path, value, fmt.Sprintf("requires %s other than %s", methodField, AddressDisabled))}
+	}
+	return nil
+}
+
+// validateMemberNames 校验成员接口名称有效、不重复且不是接口本身
+func (iface *Interface) validateMemberNames(path *validation.Path, members []string) validation.ErrorList {
+	var errs validation.ErrorList
+	for i, member := range members {
+		switch {
+		case !interfaceName.MatchString(member):
+			errs = append(errs, validation.Invalid(path.Index(i), member, "must be a network interface name of at most 15 characters"))
+		case member == iface.Name:
+			errs = append(errs, validation.Invalid(path.Index(i), member, "must differ from the interface name"))
+		case slices.Contains(members[:i], member):
+			errs = append(errs, validation.Invalid(path.Index(i), member, "is duplicated"))
+		}
+	}
+	return errs
+}
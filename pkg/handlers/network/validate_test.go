@@ -0,0 +1,102 @@
+package network
+
+import (
+	"slices"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []string // 出错的字段
+	}{
+		{
+			name: "valid dhcp",
+			spec: `{"interfaces": [{"name": "eth0", "ipv4Method": "dhcp", "ipv6Method": "auto",
+				"dhcp": {"clientId": "duid", "sendHostname": false, "hostname": "node-1", "routeMetric": 100, "ignoreDNS": true}}]}`,
+		},
+		{
+			name: "static without address",
+			spec: `{"interfaces": [{"name": "eth0", "ipv4Method": "static"}]}`,
+			want: []string{"spec.interfaces[0].ipAddress"},
+		},
+		{
+			name: "address with slaac",
+			spec: `{"interfaces": [{"name": "eth0", "ipv6Method": "slaac", "ipv6Address": "2001:db8::2/64", "ipv6Gateway": "2001:db8::1"}]}`,
+			want: []string{"spec.interfaces[0].ipv6Address", "spec.interfaces[0].ipv6Gateway"},
+		},
+		{
+			name: "invalid dhcp",
+			spec: `{"interfaces": [{"name": "eth0", "ipv4Method": "bootp", "dhcp": {"clientId": "hex", "routeMetric": -1}}]}`,
+			want: []string{"spec.interfaces[0].ipv4Method", "spec.interfaces[0].dhcp.clientId", "spec.interfaces[0].dhcp.routeMetric"},
+		},
+		{
+			name: "valid routes",
+			spec: `{"interfaces": [{"name": "eth0", "addresses": ["192.168.1.11/24", "2001:db8::11/64"],
+				"routes": [{"to": "10.10.0.0/16", "via": "192.168.1.254", "metric": 100, "table": 200, "onLink": true}, {"to": "2001:db8:1::/48"}]}]}`,
+		},
+		{
+			name: "invalid routes",
+			spec: `{"interfaces": [{"name": "eth0", "ipAddress": "192.168.1.10/24", "addresses": ["10.0.0.1", "2001:db8::11/64"], "ipv6Method": "disabled",
+				"routes": [{"via": "192.168.1.254"}, {"to": "10.10.0.0/16", "via": "2001:db8::1", "onLink": true, "metric": -1}, {"to": "2001:db8:1::/48"}]}]}`,
+			want: []string{
+				"spec.interfaces[0].addresses[0]",
+				"spec.interfaces[0].addresses[1]",
+				"spec.interfaces[0].routes[0].to",
+				"spec.interfaces[0].routes[1].via",
+				"spec.interfaces[0].routes[1].metric",
+				"spec.interfaces[0].routes[2].to",
+			},
+		},
+		{
+			name: "valid vlan",
+			spec: `{"interfaces": [{"name": "eth0.100", "vlan": {"link": "eth0", "id": 100}, "ipv4Method": "dhcp"}]}`,
+		},
+		{
+			name: "invalid vlan",
+			spec: `{"interfaces": [{"name": "vlan0", "vlan": {"link": "vlan0", "id": 4095}}, {"name": "vlan1", "vlan": {"id": 1}}]}`,
+			want: []string{"spec.interfaces[0].vlan.link", "spec.interfaces[0].vlan.id", "spec.interfaces[1].vlan.link"},
+		},
+		{
+			name: "valid bond and bridge",
+			spec: `{"interfaces": [{"name": "br0", "bridge": {"members": ["bond0"], "stp": true}, "ipv4Method": "dhcp"},
+				{"name": "bond0", "bond": {"mode": "802.3ad", "members": ["eth0", "eth1"]}}, {"name": "eth0", "mtu": 9000}]}`,
+		},
+		{
+			name: "invalid bond and bridge",
+			spec: `{"interfaces": [
+				{"name": "bond0", "bond": {"mode": "lacp", "primary": "eth2", "members": ["eth0", "eth0", "bond0"]}},
+				{"name": "br0", "bridge": {"members": ["eth0"], "forwardDelay": 31}, "vlan": {"link": "eth1", "id": 10}},
+				{"name": "eth0", "ipAddress": "192.168.1.10/24"},
+				{"name": "bond1", "bond": {}}
+			]}`,
+			want: []string{
+				"spec.interfaces[0].bond.mode",
+				"spec.interfaces[0].bond.members[1]",
+				"spec.interfaces[0].bond.members[2]",
+				"spec.interfaces[0].bond.primary",
+				"spec.interfaces[1].name",
+				"spec.interfaces[1].bridge.forwardDelay",
+				"spec.interfaces[3].bond.members",
+				"spec.interfaces[1].bridge.members[0]",
+				"spec.interfaces[2].name",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validation.DecodeSpec([]byte(tt.spec), &Config{})
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got errors %v, want fields %v", errs, tt.want)
+			}
+		})
+	}
+}
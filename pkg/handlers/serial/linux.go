@@ -2,99 +2,136 @@ package serial
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os/exec"
-	"sync"
+	"os"
+	"time"
 
 	"go.xbrother.com/nix-operator/pkg/config"
 	"go.xbrother.com/nix-operator/pkg/controller"
+	"go.xbrother.com/nix-operator/pkg/utils"
 )
 
 type Config struct {
-	Device      string             `json:"device"`
-	DisplayName string             `json:"displayName"`
-	BaudRate    int                `json:"baudRate"`
-	DataBits    int                `json:"dataBits"`
-	StopBits    int                `json:"stopBits"`
-	Parity      string             `json:"parity"`
-	Mode        string             `json:"mode"`                  // "rs232" 或 "rs485"
-	RS485       *RS485Config       `json:"rs485,omitempty"`       // RS485 特定配置
-	Transparent *TransparentConfig `json:"transparent,omitempty"` // 透传配置
+	Device      string             `json:"device" required:"true" pattern:"^/dev/" zh:"串口设备路径" en:"Serial device path" placeholder:"/dev/ttyS0"`
+	DisplayName string             `json:"displayName" zh:"显示名称" en:"Display name" placeholder:"COM1"`
+	BaudRate    int                `json:"baudRate" required:"true" ui:"select" enum:"50,75,110,134,150,200,300,600,1200,1800,2400,4800,9600,19200,38400,57600,115200,230400,460800,500000,576000,921600,1000000,1152000,1500000,2000000,2500000,3000000,3500000,4000000" zh:"波特率" en:"Baud rate" default:"9600"`
+	DataBits    int                `json:"dataBits" required:"true" enum:"5,6,7,8" zh:"数据位" en:"Data bits" default:"8"`
+	StopBits    int                `json:"stopBits" required:"true" enum:"1,2" zh:"停止位" en:"Stop bits" default:"1"`
+	Parity      string             `json:"parity" required:"true" enum:"none,even,odd" zh:"校验位" en:"Parity" default:"none"`
+	Mode        string             `json:"mode" enum:"rs232,rs485" zh:"串口模式" en:"Serial mode" default:"rs232"`
+	RS485       *RS485Config       `json:"rs485,omitempty" zh:"RS485 配置" en:"RS485 settings"`
+	Transparent *TransparentConfig `json:"transparent,omitempty" zh:"网络透传配置" en:"Network passthrough settings"`
 }
 
 type RS485Config struct {
-	Enabled            bool `json:"enabled"`            // 启用 RS485 模式
-	RTSOnSend          bool `json:"rtsOnSend"`          // 发送时 RTS 信号状态
-	RTSAfterSend       bool `json:"rtsAfterSend"`       // 发送后 RTS 信号状态
-	RTSDelay           int  `json:"rtsDelay"`           // RTS 延迟时间（微秒）
-	DelayRTSBeforeSend int  `json:"delayRTSBeforeSend"` // 发送前 RTS 延迟（微秒）
-	DelayRTSAfterSend  int  `json:"delayRTSAfterSend"`  // 发送后 RTS 延迟（微秒）
-	ReceiveTimeout     int  `json:"receiveTimeout"`     // 接收超时（毫秒）
+	Enabled            bool `json:"enabled" zh:"启用 RS485 模式" en:"Enable RS485 mode"`
+	RTSOnSend          bool `json:"rtsOnSend" zh:"发送时 RTS 信号状态" en:"RTS level when sending"`
+	RTSAfterSend       bool `json:"rtsAfterSend" zh:"发送后 RTS 信号状态" en:"RTS level after sending"`
+	RTSDelay           int  `json:"rtsDelay" ui:"updown" minimum:"0" zh:"RTS 延迟时间（微秒）" en:"RTS delay (microseconds)"`
+	DelayRTSBeforeSend int  `json:"delayRTSBeforeSend" ui:"updown" minimum:"0" zh:"发送前 RTS 延迟（微秒）" en:"RTS delay before send (microseconds)"`
+	DelayRTSAfterSend  int  `json:"delayRTSAfterSend" ui:"updown" minimum:"0" zh:"发送后 RTS 延迟（微秒）" en:"RTS delay after send (microseconds)"`
+	ReceiveTimeout     int  `json:"receiveTimeout" ui:"updown" minimum:"0" zh:"接收超时（毫秒）" en:"Receive timeout (milliseconds)"`
 }
 
 type TransparentConfig struct {
-	Enabled    bool   `json:"enabled"`    // 启用透传功能
-	Protocol   string `json:"protocol"`   // 透传协议 "tcp" 或 "udp"
-	ListenAddr string `json:"listenAddr"` // 监听地址，如 "0.0.0.0:8080"
-	BufferSize int    `json:"bufferSize"` // 缓冲区大小（字节）
-	Timeout    int    `json:"timeout"`    // 连接超时（秒）
+	Enabled    bool   `json:"enabled" zh:"启用透传功能" en:"Enable passthrough"`
+	Protocol   string `json:"protocol" enum:"tcp,udp" zh:"透传协议" en:"Passthrough protocol" default:"tcp"`
+	ListenAddr string `json:"listenAddr" zh:"监听地址" en:"Listen address" placeholder:"0.0.0.0:8080"`
+	BufferSize int    `json:"bufferSize" ui:"updown" minimum:"0" zh:"缓冲区大小（字节）" en:"Buffer size (bytes)"`
+	Timeout    int    `json:"timeout" ui:"updown" minimum:"0" zh:"连接超时（秒）" en:"Connection timeout (seconds)"`
 }
 
+// deviceRetryInterval 是串口设备不存在时重新调谐的间隔
+const deviceRetryInterval = 30 * time.Second
+
 func init() {
+	controller.RegisterSpec("SerialConfiguration", &Config{})
 	controller.RegisterHandler("SerialConfiguration", &LinuxSerialHandler{modeSwitcher: &LightingAModeSwitcher{}})
 	controller.RegisterHandler("SerialConfiguration", &LinuxSerialHandler{modeSwitcher: &LightingBModeSwitcher{}})
 	controller.RegisterHandler("SerialConfiguration", &LinuxSerialHandler{modeSwitcher: &RainbowBModeSwitcher{}})
 }
 
 type LinuxSerialHandler struct {
-	modeSwitcher       ModeSwitcher
-	transparentServers map[string]*TransparentServer
-	mu                 *sync.RWMutex
+	modeSwitcher ModeSwitcher
 }
 
 func (f *LinuxSerialHandler) Match(osInfo controller.OSInfo) bool {
 	return osInfo.KernelName == "Linux" && f.modeSwitcher.Match(osInfo)
 }
 
-func (h *LinuxSerialHandler) Reconcile(ctx context.Context, cfg *config.SystemConfiguration) error {
-	if h.transparentServers == nil {
-		h.transparentServers = make(map[string]*TransparentServer)
+func (h *LinuxSerialHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*controller.ReconcileResult, error) {
+	// 解析串口配置，每个资源描述一个串口
+	var serial Config
+	if err := json.Unmarshal(cfg.Spec, &serial); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal serial spec: %v", err)
+	}
+
+	// 设备尚未就绪（如 USB 串口未插入）时稍后重试
+	if _, err := utils.FSFromContext(ctx).Stat(serial.Device); os.IsNotExist(err) {
+		return &controller.ReconcileResult{
+			Status: &config.ResourceStatus{
+				Phase:   config.PhasePending,
+				Reason:  "DeviceNotFound",
+				Message: fmt.Sprintf("serial device %s not found", serial.Device),
+			},
+			RequeueAfter: deviceRetryInterval,
+		}, nil
+	}
+
+	// 配置基本串口参数
+	if err := h.configureSerialParams(ctx, serial); err != nil {
+		return nil, err
+	}
+
+	// 配置 RS232/RS485 模式
+	if err := h.configureSerialMode(ctx, serial); err != nil {
+		return nil, err
 	}
 
-	for _, serial := range cfg.Spec.Serials {
-		// 配置基本串口参数
-		if err := h.configureSerialParams(ctx, serial); err != nil {
-			return err
-		}
-
-		// 配置 RS232/RS485 模式
-		if err := h.configureSerialMode(ctx, serial); err != nil {
-			return err
-		}
-
-		// 配置透传功能
-		if err := h.configureTransparent(ctx, serial); err != nil {
-			return err
-		}
+	// 配置透传功能
+	if err := h.configureTransparent(ctx, serial); err != nil {
+		return nil, err
 	}
+
+	return &controller.ReconcileResult{Effective: cfg}, nil
+}
+
+// Plan 预览配置串口参数的命令，RS485 模式通过 ioctl 配置，不在预览中列出
+func (h *LinuxSerialHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*controller.Plan, error) {
+	var serial Config
+	if err := json.Unmarshal(cfg.Spec, &serial); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal serial spec: %v", err)
+	}
+	return &controller.Plan{Commands: [][]string{sttyCommand(serial)}}, nil
+}
+
+// Cleanup 保持串口参数不变，透传尚未实现，没有需要停止的服务
+// 透传实现后在这里停止该串口的服务，并实现 controller.Stopper 在退出时停止所有服务
+func (h *LinuxSerialHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
 	return nil
 }
 
-func (h *LinuxSerialHandler) configureSerialParams(ctx context.Context, serial config.SerialConfig) error {
-	cmd := exec.CommandContext(ctx, "stty",
+// sttyCommand 返回配置基本串口参数的命令
+func sttyCommand(serial Config) []string {
+	return []string{"stty",
 		"-F", serial.Device,
 		fmt.Sprintf("%d", serial.BaudRate),
 		fmt.Sprintf("cs%d", serial.DataBits),
 		fmt.Sprintf("-%s", serial.Parity),
-		fmt.Sprintf("-%sstopb", map[int]string{1: "", 2: "-"}[serial.StopBits]))
-	output, err := cmd.CombinedOutput()
+		fmt.Sprintf("-%sstopb", map[int]string{1: "", 2: "-"}[serial.StopBits])}
+}
+
+func (h *LinuxSerialHandler) configureSerialParams(ctx context.Context, serial Config) error {
+	args := sttyCommand(serial)
+	output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...)
 	if err != nil {
 		return fmt.Errorf("failed to configure serial port %s: %v, output: %s", serial.Device, err, output)
 	}
 	return nil
 }
 
-func (h *LinuxSerialHandler) configureSerialMode(ctx context.Context, serial config.SerialConfig) error {
+func (h *LinuxSerialHandler) configureSerialMode(ctx context.Context, serial Config) error {
 	if serial.Mode == "" {
 		return nil // 如果没有指定模式，跳过
 	}
@@ -110,13 +147,13 @@ func (h *LinuxSerialHandler) configureSerialMode(ctx context.Context, serial con
 	return nil
 }
 
-func (h *LinuxSerialHandler) configureTransparent(ctx context.Context, serial config.SerialConfig) error {
+func (h *LinuxSerialHandler) configureTransparent(ctx context.Context, serial Config) error {
 	// 如果没有透传配置或未启用，跳过
 	if serial.Transparent == nil || !serial.Transparent.Enabled {
 		return nil
 	}
 
 	// TODO: 实现串口透传功能
-	// 这里需要实现 TCP/UDP 服务器和串口数据转发逻辑
+	// 这里需要实现 TCP/UDP 服务器和串口数据转发逻辑，以及会话的字节数和连接数指标
 	return nil
 }
@@ -4,13 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"unsafe"
 
+	"go.xbrother.com/nix-operator/pkg/utils"
 	"golang.org/x/sys/unix"
-
-	"go.xbrother.com/nix-operator/pkg/config"
 )
 
 // RS485 ioctl 常量
@@ -35,10 +33,10 @@ type rs485Config struct {
 	Padding            [5]uint32 // 填充字段
 }
 
-func configureRS485(ctx context.Context, serial config.SerialConfig) error {
+func configureRS485(ctx context.Context, serial Config) error {
 	// 如果没有 RS485 配置，使用默认配置
 	if serial.RS485 == nil {
-		serial.RS485 = &config.RS485Config{
+		serial.RS485 = &RS485Config{
 			Enabled:            true,
 			RTSOnSend:          true,
 			RTSAfterSend:       false,
@@ -48,7 +46,7 @@ func configureRS485(ctx context.Context, serial config.SerialConfig) error {
 	}
 
 	// 打开串口设备
-	file, err := os.OpenFile(serial.Device, os.O_RDWR, 0)
+	file, err := utils.FSFromContext(ctx).OpenFile(serial.Device, os.O_RDWR, 0)
 	if err != nil {
 		return fmt.Errorf("failed to open serial device %s: %v", serial.Device, err)
 	}
@@ -88,7 +86,7 @@ func configureRS485(ctx context.Context, serial config.SerialConfig) error {
 	return nil
 }
 
-func configureRS485WithSetserial(ctx context.Context, serial config.SerialConfig) error {
+func configureRS485WithSetserial(ctx context.Context, serial Config) error {
 	// 使用 setserial 命令配置 RS485 模式（备选方案）
 	args := []string{serial.Device}
 
@@ -100,8 +98,7 @@ func configureRS485WithSetserial(ctx context.Context, serial config.SerialConfig
 		args = append(args, "rts_delay", strconv.Itoa(serial.RS485.DelayRTSBeforeSend))
 	}
 
-	cmd := exec.CommandContext(ctx, "setserial", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := utils.RunnerFromContext(ctx).Run(ctx, "setserial", args...)
 	if err != nil {
 		return fmt.Errorf("failed to configure RS485 with setserial for %s: %v, output: %s", serial.Device, err, output)
 	}
@@ -0,0 +1,47 @@
+package serial
+
+import (
+	"strings"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// Validate 校验串口参数、RS485 延迟和透传配置
+func (c *Config) Validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	switch {
+	case c.Device == "":
+		errs = append(errs, validation.Required(path.Child("device")))
+	case !strings.HasPrefix(c.Device, "/dev/"):
+		errs = append(errs, validation.Invalid(path.Child("device"), c.Device, "must be a device path under /dev"))
+	}
+
+	errs = append(errs, validation.ValidateBaudRate(path.Child("baudRate"), c.BaudRate)...)
+	errs = append(errs, validation.ValidateOneOf(path.Child("dataBits"), c.DataBits, 5, 6, 7, 8)...)
+	errs = append(errs, validation.ValidateOneOf(path.Child("stopBits"), c.StopBits, 1, 2)...)
+	errs = append(errs, validation.ValidateOneOf(path.Child("parity"), c.Parity, "none", "even", "odd")...)
+	if c.Mode != "" {
+		errs = append(errs, validation.ValidateOneOf(path.Child("mode"), c.Mode, "rs232", "rs485")...)
+	}
+
+	if c.RS485 != nil {
+		rs485Path := path.Child("rs485")
+		errs = append(errs, validation.ValidateNonNegative(rs485Path.Child("rtsDelay"), c.RS485.RTSDelay)...)
+		errs = append(errs, validation.ValidateNonNegative(rs485Path.Child("delayRTSBeforeSend"), c.RS485.DelayRTSBeforeSend)...)
+		errs = append(errs, validation.ValidateNonNegative(rs485Path.Child("delayRTSAfterSend"), c.RS485.DelayRTSAfterSend)...)
+		errs = append(errs, validation.ValidateNonNegative(rs485Path.Child("receiveTimeout"), c.RS485.ReceiveTimeout)...)
+	}
+
+	if c.Transparent != nil && c.Transparent.Enabled {
+		transparentPath := path.Child("transparent")
+		errs = append(errs, validation.ValidateOneOf(transparentPath.Child("protocol"), c.Transparent.Protocol, "tcp", "udp")...)
+		if c.Transparent.ListenAddr == "" {
+			errs = append(errs, validation.Required(transparentPath.Child("listenAddr")))
+		} else {
+			errs = append(errs, validation.ValidateHostPort(transparentPath.Child("listenAddr"), c.Transparent.ListenAddr)...)
+		}
+		errs = append(errs, validation.ValidateNonNegative(transparentPath.Child("bufferSize"), c.Transparent.BufferSize)...)
+		errs = append(errs, validation.ValidateNonNegative(transparentPath.Child("timeout"), c.Transparent.Timeout)...)
+	}
+	return errs
+}
@@ -0,0 +1,57 @@
+package serial
+
+import (
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []string // 出错的字段
+	}{
+		{
+			name: "valid rs485",
+			spec: `{"device": "/dev/ttyS1", "baudRate": 9600, "dataBits": 8, "stopBits": 1, "parity": "none", "mode": "rs485",
+				"rs485": {"enabled": true, "rtsOnSend": true, "delayRTSBeforeSend": 0},
+				"transparent": {"enabled": true, "protocol": "tcp", "listenAddr": "0.0.0.0:8080", "bufferSize": 4096, "timeout": 30}}`,
+		},
+		{
+			name: "misspelled transparent enabled",
+			spec: `{"device": "/dev/ttyS0", "baudRate": 115200, "dataBits": 8, "stopBits": 1, "parity": "none",
+				"transparent": {"enable": true, "protocol": "tcp", "listenAddr": "0.0.0.0:8080"}}`,
+			want: []string{"spec.transparent.enable"},
+		},
+		{
+			name: "invalid parameters",
+			spec: `{"device": "ttyS0", "baudRate": 115201, "dataBits": 9, "stopBits": 3, "parity": "mark", "mode": "rs422"}`,
+			want: []string{"spec.device", "spec.baudRate", "spec.dataBits", "spec.stopBits", "spec.parity", "spec.mode"},
+		},
+		{
+			name: "invalid transparent",
+			spec: `{"device": "/dev/ttyS0", "baudRate": 9600, "dataBits": 8, "stopBits": 1, "parity": "even",
+				"transparent": {"enabled": true, "protocol": "websocket", "listenAddr": "8080", "bufferSize": -1}}`,
+			want: []string{"spec.transparent.protocol", "spec.transparent.listenAddr", "spec.transparent.bufferSize"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validation.DecodeSpec([]byte(tt.spec), &Config{})
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Field)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got errors %v, want fields %v", errs, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got field %s, want %s (%v)", got[i], tt.want[i], errs)
+				}
+			}
+		})
+	}
+}
@@ -5,8 +5,6 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
 	"text/template"
 
@@ -16,23 +14,40 @@ import (
 )
 
 type TimeSpec struct {
-	Timezone string    `json:"timezone" yaml:"timezone"`
-	NTP      NTPConfig `json:"ntp" yaml:"ntp"`
+	Timezone string    `json:"timezone" yaml:"timezone" zh:"IANA 时区名称" en:"IANA time zone name" placeholder:"Asia/Shanghai"`
+	NTP      NTPConfig `json:"ntp" yaml:"ntp" zh:"NTP 时间同步" en:"NTP time synchronization"`
 }
 
 type NTPConfig struct {
-	Enable  bool     `json:"enable" yaml:"enable"`
-	Servers []string `json:"servers" yaml:"servers"`
+	Enable  bool     `json:"enable" yaml:"enable" zh:"启用 NTP 同步" en:"Enable NTP synchronization" default:"false"`
+	Servers []string `json:"servers" yaml:"servers" zh:"NTP 服务器地址" en:"NTP server addresses" placeholder:"ntp.aliyun.com"`
+}
+
+const (
+	chronyConfigPath = "/etc/chrony.conf"
+	localtimePath    = "/etc/localtime"
+)
+
+// chronyReloadCommand 重新加载 chrony 配置（不需要 root 权限）
+var chronyReloadCommand = []string{"chronyc", "reload", "sources"}
+
+// timezoneCommand 使用 timedatectl 设置时区
+func timezoneCommand(timezone string) []string {
+	return []string{"timedatectl", "set-timezone", timezone}
 }
 
 //go:embed chrony.conf.tpl
 var chronyConfigTemplate string
 
 func init() {
+	controller.RegisterSpec("TimeConfiguration", &TimeSpec{})
 	controller.RegisterHandler("TimeConfiguration", &LinuxTimeHandler{})
 }
 
-type LinuxTimeHandler struct{}
+type LinuxTimeHandler struct {
+	// reloads 记录配置已写入但 chronyd 重新加载失败的配置文件，重试时再次重新加载
+	reloads utils.PendingReloads
+}
 
 func (h *LinuxTimeHandler) Match(osInfo controller.OSInfo) bool {
 	return osInfo.KernelName == "Linux"
@@ -42,22 +57,23 @@ type chronyConfig struct {
 	Servers []string
 }
 
-func (h *LinuxTimeHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) error {
-	// 解析时间配置
-	var timeSpec TimeSpec
+func (h *LinuxTimeHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*controller.ReconcileResult, error) {
+	if err := h.reconcile(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return &controller.ReconcileResult{Effective: cfg}, nil
+}
 
-	// 将Spec转换为时间配置
-	specBytes, err := json.Marshal(cfg.Spec)
+func (h *LinuxTimeHandler) reconcile(ctx context.Context, cfg *config.ResourceConfig) error {
+	timeSpec, err := h.parseSpec(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to marshal spec: %v", err)
+		return err
 	}
 
-	if err := json.Unmarshal(specBytes, &timeSpec); err != nil {
-		return fmt.Errorf("failed to unmarshal time spec: %v", err)
-	}
+	fsys := utils.FSFromContext(ctx)
 
 	// 设置时区
-	if timeSpec.Timezone != "" {
+	if timeSpec.Timezone != "" && timeSpec.Timezone != h.currentTimezone(fsys) {
 		if err := h.setTimezone(ctx, timeSpec.Timezone); err != nil {
 			return fmt.Errorf("failed to set timezone: %v", err)
 		}
@@ -68,6 +84,88 @@ func (h *LinuxTimeHandler) Reconcile(ctx context.Context, cfg *config.ResourceCo
 		return nil
 	}
 
+	desiredContent, err := h.renderChronyConfig(timeSpec)
+	if err != nil {
+		return err
+	}
+
+	// 读取现有配置，文件不存在、读取失败或内容不同时写入新配置
+	currentContent, err := fsys.ReadFile(chronyConfigPath)
+	if err != nil || string(currentContent) != desiredContent {
+		// 首次接管前备份原文件
+		if err := utils.BackupFile(fsys, chronyConfigPath); err != nil {
+			return err
+		}
+
+		// 原子性写入文件
+		if err := fsys.WriteFile(chronyConfigPath, []byte(desiredContent), 0644); err != nil {
+			return fmt.Errorf("failed to write chrony.conf: %v", err)
+		}
+		h.reloads.Mark(chronyConfigPath)
+	}
+
+	// 配置相同时只补上之前失败的重新加载
+	return h.reloads.Reload(chronyConfigPath, func() error { return h.reloadChrony(ctx) })
+}
+
+// Render 生成期望的 chrony 配置，未启用 NTP 时不管理任何文件
+func (h *LinuxTimeHandler) Render(ctx context.Context, cfg *config.ResourceConfig) ([]controller.RenderedFile, error) {
+	timeSpec, err := h.parseSpec(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !timeSpec.NTP.Enable {
+		return nil, nil
+	}
+
+	content, err := h.renderChronyConfig(timeSpec)
+	if err != nil {
+		return nil, err
+	}
+	return []controller.RenderedFile{{Path: chronyConfigPath, Content: []byte(content), Mode: 0644}}, nil
+}
+
+// Plan 预览 chrony 配置的变更以及需要执行的时区设置和重新加载命令
+func (h *LinuxTimeHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*controller.Plan, error) {
+	timeSpec, err := h.parseSpec(cfg)
+	if err != nil {
+		return nil, err
+	}
+	files, err := h.Render(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := controller.NewPlan(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeSpec.Timezone != "" && timeSpec.Timezone != h.currentTimezone(utils.FSFromContext(ctx)) {
+		plan.Commands = append(plan.Commands, timezoneCommand(timeSpec.Timezone))
+	}
+	if len(plan.Files) > 0 {
+		plan.Commands = append(plan.Commands, chronyReloadCommand)
+	}
+	return plan, nil
+}
+
+func (h *LinuxTimeHandler) parseSpec(cfg *config.ResourceConfig) (TimeSpec, error) {
+	// 解析时间配置
+	var timeSpec TimeSpec
+
+	// 将Spec转换为时间配置
+	specBytes, err := json.Marshal(cfg.Spec)
+	if err != nil {
+		return timeSpec, fmt.Errorf("failed to marshal spec: %v", err)
+	}
+
+	if err := json.Unmarshal(specBytes, &timeSpec); err != nil {
+		return timeSpec, fmt.Errorf("failed to unmarshal time spec: %v", err)
+	}
+	return timeSpec, nil
+}
+
+func (h *LinuxTimeHandler) renderChronyConfig(timeSpec TimeSpec) (string, error) {
 	// 准备模板数据
 	templateData := chronyConfig{
 		Servers: timeSpec.NTP.Servers,
@@ -76,48 +174,60 @@ func (h *LinuxTimeHandler) Reconcile(ctx context.Context, cfg *config.ResourceCo
 	// 解析模板
 	tmpl, err := template.New("chrony").Parse(chronyConfigTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to parse template: %v", err)
+		return "", fmt.Errorf("failed to parse template: %v", err)
 	}
 
 	// 渲染配置
 	var content strings.Builder
 	if err := tmpl.Execute(&content, templateData); err != nil {
-		return fmt.Errorf("failed to execute template: %v", err)
+		return "", fmt.Errorf("failed to execute template: %v", err)
 	}
 
-	desiredContent := content.String()
+	return content.String(), nil
+}
 
-	// 读取现有配置
-	currentContent, err := os.ReadFile("/etc/chrony.conf")
-	if err == nil {
-		// 配置文件存在，比较内容
-		if string(currentContent) == desiredContent {
-			return nil // 配置相同，无需更新
-		}
-	}
-	// 如果文件不存在或读取失败，继续写入新配置
+// Exclusive chrony 配置和时区都是全局的，只允许一个 TimeConfiguration 资源
+func (h *LinuxTimeHandler) Exclusive() bool {
+	return true
+}
 
-	// 使用工具函数原子性写入文件
-	if err := utils.AtomicWriteFile([]byte(desiredContent), "/etc/chrony.conf", 0644); err != nil {
-		return fmt.Errorf("failed to write chrony.conf: %v", err)
+// Cleanup 恢复 nix-operator 接管前的 chrony 配置，时区保持不变
+func (h *LinuxTimeHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	changed, err := utils.RevertFile(utils.FSFromContext(ctx), chronyConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to revert chrony.conf: %v", err)
 	}
+	if changed {
+		h.reloads.Mark(chronyConfigPath)
+	}
+	return h.reloads.Reload(chronyConfigPath, func() error { return h.reloadChrony(ctx) })
+}
 
-	// 重新加载配置（不需要 root 权限）
-	cmd := exec.CommandContext(ctx, "chronyc", "reload", "sources")
-	output, err := cmd.CombinedOutput()
+func (h *LinuxTimeHandler) reloadChrony(ctx context.Context) error {
+	output, err := utils.RunnerFromContext(ctx).Run(ctx, chronyReloadCommand[0], chronyReloadCommand[1:]...)
 	if err != nil {
 		return fmt.Errorf("failed to reload chronyd config: %v, output: %s", err, output)
 	}
-
 	return nil
 }
 
 func (h *LinuxTimeHandler) setTimezone(ctx context.Context, timezone string) error {
-	// 使用timedatectl设置时区
-	cmd := exec.CommandContext(ctx, "timedatectl", "set-timezone", timezone)
-	output, err := cmd.CombinedOutput()
+	args := timezoneCommand(timezone)
+	output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...)
 	if err != nil {
 		return fmt.Errorf("failed to set timezone: %v, output: %s", err, output)
 	}
 	return nil
 }
+
+// currentTimezone 根据 /etc/localtime 链接的目标获取当前时区，无法确定时返回空字符串
+func (h *LinuxTimeHandler) currentTimezone(fsys utils.FS) string {
+	target, err := fsys.Readlink(localtimePath)
+	if err != nil {
+		return ""
+	}
+	if _, zone, ok := strings.Cut(target, "zoneinfo/"); ok {
+		return zone
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package time
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		files int
+	}{
+		{
+			name:  "chrony",
+			spec:  `{"timezone": "Asia/Shanghai", "ntp": {"enable": true, "servers": ["ntp1.aliyun.com", "ntp2.aliyun.com"]}}`,
+			files: 1,
+		},
+		{
+			name:  "ntp-disabled",
+			spec:  `{"timezone": "Asia/Shanghai", "ntp": {"enable": false}}`,
+			files: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := testutil.NewRoot(t, "/etc")
+			h := &LinuxTimeHandler{}
+			cfg := &config.ResourceConfig{Kind: "TimeConfiguration", Spec: []byte(tt.spec)}
+
+			files, err := h.Render(testutil.Context(fsys, &testutil.FakeRunner{}), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.files {
+				t.Fatalf("got %d files, want %d", len(files), tt.files)
+			}
+			if tt.files > 0 {
+				testutil.Golden(t, tt.name, files[0].Content)
+			}
+		})
+	}
+}
+
+func TestCurrentTimezone(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc")
+	h := &LinuxTimeHandler{}
+	if tz := h.currentTimezone(fsys); tz != "" {
+		t.Errorf("got timezone %q without /etc/localtime", tz)
+	}
+
+	if err := os.Symlink("/usr/share/zoneinfo/Asia/Shanghai", fsys.Path(localtimePath)); err != nil {
+		t.Fatal(err)
+	}
+	if tz := h.currentTimezone(fsys); tz != "Asia/Shanghai" {
+		t.Errorf("got timezone %q, want Asia/Shanghai", tz)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc")
+	if err := os.Symlink("/usr/share/zoneinfo/UTC", fsys.Path(localtimePath)); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.ResourceConfig{
+		Kind: "TimeConfiguration",
+		Spec: []byte(`{"timezone": "Asia/Shanghai", "ntp": {"enable": true, "servers": ["ntp1.aliyun.com"]}}`),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		runner := &testutil.FakeRunner{}
+		h := &LinuxTimeHandler{}
+		if _, err := h.Reconcile(testutil.Context(fsys, runner), cfg); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"timedatectl set-timezone Asia/Shanghai", "chronyc reload sources"}
+		if got := runner.Commands(); !slices.Equal(got, want) {
+			t.Errorf("got commands %q, want %q", got, want)
+		}
+	})
+
+	t.Run("timezone failure", func(t *testing.T) {
+		runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+			"timedatectl set-timezone Asia/Shanghai": {Output: "Failed to set time zone: Access denied", ExitCode: 1},
+		}}
+		h := &LinuxTimeHandler{}
+		_, err := h.Reconcile(testutil.Context(fsys, runner), cfg)
+		if err == nil || !strings.Contains(err.Error(), "Access denied") {
+			t.Fatalf("expected timedatectl output in error, got %v", err)
+		}
+	})
+
+	t.Run("reload failure", func(t *testing.T) {
+		fsys := testutil.NewRoot(t, "/etc")
+		if err := os.Symlink("/usr/share/zoneinfo/Asia/Shanghai", fsys.Path(localtimePath)); err != nil {
+			t.Fatal(err)
+		}
+		runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+			"chronyc reload sources": {Output: "506 Cannot talk to daemon", ExitCode: 1},
+		}}
+		ctx := testutil.Context(fsys, runner)
+		h := &LinuxTimeHandler{}
+		if _, err := h.Reconcile(ctx, cfg); err == nil {
+			t.Fatal("expected reload failure")
+		}
+
+		// chrony.conf 已经写入，重试时仍需重新加载
+		runner.Results = nil
+		if _, err := h.Reconcile(ctx, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.Reconcile(ctx, cfg); err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"chronyc reload sources", "chronyc reload sources"}
+		if got := runner.Commands(); !slices.Equal(got, want) {
+			t.Errorf("got commands %q, want %q", got, want)
+		}
+	})
+}
+
+func TestCleanup(t *testing.T) {
+	const original = "pool pool.ntp.org iburst\n"
+	fsys := testutil.NewRoot(t, "/etc")
+	if err := os.Symlink("/usr/share/zoneinfo/Asia/Shanghai", fsys.Path(localtimePath)); err != nil {
+		t.Fatal(err)
+	}
+	testutil.WriteFile(t, fsys, chronyConfigPath, original)
+	cfg := &config.ResourceConfig{
+		Kind: "TimeConfiguration",
+		Spec: []byte(`{"timezone": "Asia/Shanghai", "ntp": {"enable": true, "servers": ["ntp1.aliyun.com"]}}`),
+	}
+	runner := &testutil.FakeRunner{}
+	ctx := testutil.Context(fsys, runner)
+	h := &LinuxTimeHandler{}
+
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Cleanup(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fsys.ReadFile(chronyConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(restored) != original {
+		t.Errorf("chrony.conf not restored after cleanup, got:\n%s", restored)
+	}
+	want := []string{"chronyc reload sources", "chronyc reload sources"}
+	if got := runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+
+	// 已恢复的配置不再重新加载
+	if err := h.Cleanup(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != len(want) {
+		t.Errorf("unexpected commands after second cleanup: %q", got[len(want):])
+	}
+}
@@ -0,0 +1,22 @@
+package time
+
+import (
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// Validate 校验时区名称和 NTP 服务器地址
+func (s *TimeSpec) Validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	if s.Timezone != "" {
+		errs = append(errs, validation.ValidateTimezone(path.Child("timezone"), s.Timezone)...)
+	}
+
+	ntpPath := path.Child("ntp")
+	if s.NTP.Enable && len(s.NTP.Servers) == 0 {
+		errs = append(errs, validation.Required(ntpPath.Child("servers")))
+	}
+	for i, server := range s.NTP.Servers {
+		errs = append(errs, validation.ValidateHost(ntpPath.Child("servers").Index(i), server)...)
+	}
+	return errs
+}
@@ -2,9 +2,10 @@ package udev
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"slices"
 	"strings"
 
 	"go.xbrother.com/nix-operator/pkg/config"
@@ -13,17 +14,26 @@ import (
 )
 
 type Config struct {
-	Rules []UdevRule `json:"rules"`
+	Rules []UdevRule `json:"rules" zh:"udev 规则" en:"udev rules"`
 }
 
 type UdevRule struct {
-	Name      string            `json:"name"`
-	Subsystem string            `json:"subsystem"`
-	Attrs     map[string]string `json:"attrs"`
-	Symlink   string            `json:"symlink"`
+	Name      string            `json:"name" required:"true" zh:"规则名称" en:"Rule name" placeholder:"usb-serial"`
+	Subsystem string            `json:"subsystem" required:"true" zh:"设备子系统" en:"Device subsystem" placeholder:"tty"`
+	Attrs     map[string]string `json:"attrs" zh:"匹配的 sysfs 属性" en:"sysfs attributes to match"`
+	Symlink   string            `json:"symlink" required:"true" zh:"设备符号链接名称" en:"Device symlink name" placeholder:"ttyUSB-modbus"`
+}
+
+const rulesPath = "/etc/udev/rules.d/99-nix-operator.rules"
+
+// reloadCommands 是规则变化后重新加载并触发 udev 规则的命令
+var reloadCommands = [][]string{
+	{"udevadm", "control", "--reload-rules"},
+	{"udevadm", "trigger"},
 }
 
 func init() {
+	controller.RegisterSpec("UdevConfiguration", &Config{})
 	controller.RegisterHandler("UdevConfiguration", &LinuxUdevHandler{})
 }
 
@@ -31,53 +41,117 @@ func (f *LinuxUdevHandler) Match(osInfo controller.OSInfo) bool {
 	return osInfo.KernelName == "Linux"
 }
 
-type LinuxUdevHandler struct{}
+type LinuxUdevHandler struct {
+	// reloads 记录规则已写入但重新加载失败的规则文件，重试时再次重新加载
+	reloads utils.PendingReloads
+}
 
-func (h *LinuxUdevHandler) Reconcile(ctx context.Context, cfg *config.SystemConfiguration) error {
+func (h *LinuxUdevHandler) Reconcile(ctx context.Context, cfg *config.ResourceConfig) (*controller.ReconcileResult, error) {
+	// 解析 udev 配置
+	var udevSpec Config
+	if err := json.Unmarshal(cfg.Spec, &udevSpec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal udev spec: %v", err)
+	}
+
+	if err := h.reconcile(ctx, udevSpec); err != nil {
+		return nil, err
+	}
+	return &controller.ReconcileResult{Effective: cfg}, nil
+}
+
+func (h *LinuxUdevHandler) reconcile(ctx context.Context, udevSpec Config) error {
 	// 生成期望的 udev 规则内容
-	desiredContent := h.generateUdevRules(cfg.Spec.Udev.Rules)
+	desiredContent := h.generateUdevRules(udevSpec.Rules)
+
+	fsys := utils.FSFromContext(ctx)
 
 	// 读取现有的 udev 规则文件
-	currentContent, err := h.getCurrentUdevRules()
+	currentContent, err := h.getCurrentUdevRules(fsys)
 	if err != nil {
 		return fmt.Errorf("failed to read current udev rules: %v", err)
 	}
 
-	// 比较现有配置和期望配置
-	if currentContent == desiredContent {
-		return nil // 配置一致，无需更新
+	// 比较现有配置和期望配置，一致时只补上之前失败的重新加载
+	if currentContent != desiredContent {
+		// 原子性写入文件
+		if err := fsys.WriteFile(rulesPath, []byte(desiredContent), 0644); err != nil {
+			return fmt.Errorf("failed to write udev rules: %v", err)
+		}
+		h.reloads.Mark(rulesPath)
 	}
 
-	// 使用工具函数原子性写入文件
-	if err := utils.AtomicWriteFile([]byte(desiredContent), "/etc/udev/rules.d/99-nix-operator.rules", 0644); err != nil {
-		return fmt.Errorf("failed to write udev rules: %v", err)
+	return h.reloads.Reload(rulesPath, func() error { return h.reloadRules(ctx) })
+}
+
+// Render 生成期望的 udev 规则文件
+func (h *LinuxUdevHandler) Render(ctx context.Context, cfg *config.ResourceConfig) ([]controller.RenderedFile, error) {
+	var udevSpec Config
+	if err := json.Unmarshal(cfg.Spec, &udevSpec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal udev spec: %v", err)
 	}
 
-	// 重新加载 udev 规则
-	cmd := exec.CommandContext(ctx, "udevadm", "control", "--reload-rules")
-	output, err := cmd.CombinedOutput()
+	content := h.generateUdevRules(udevSpec.Rules)
+	return []controller.RenderedFile{{Path: rulesPath, Content: []byte(content), Mode: 0644}}, nil
+}
+
+// Exclusive 所有规则写入同一个文件，只允许一个 UdevConfiguration 资源
+func (h *LinuxUdevHandler) Exclusive() bool {
+	return true
+}
+
+// Cleanup 删除生成的 udev 规则文件并重新加载规则
+func (h *LinuxUdevHandler) Cleanup(ctx context.Context, cfg *config.ResourceConfig) error {
+	removed, err := utils.RemoveGeneratedFile(utils.FSFromContext(ctx), rulesPath)
 	if err != nil {
-		return fmt.Errorf("failed to reload udev rules: %v, output: %s", err, output)
+		return fmt.Errorf("failed to remove udev rules: %v", err)
 	}
+	if removed {
+		h.reloads.Mark(rulesPath)
+	}
+	return h.reloads.Reload(rulesPath, func() error { return h.reloadRules(ctx) })
+}
 
-	// 触发 udev 规则
-	cmd = exec.CommandContext(ctx, "udevadm", "trigger")
-	output, err = cmd.CombinedOutput()
+// Plan 预览 udev 规则的变更，规则变化时需要重新加载并触发
+func (h *LinuxUdevHandler) Plan(ctx context.Context, cfg *config.ResourceConfig) (*controller.Plan, error) {
+	files, err := h.Render(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to trigger udev rules: %v, output: %s", err, output)
+		return nil, err
 	}
+	plan, err := controller.NewPlan(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Files) > 0 {
+		plan.Commands = reloadCommands
+	}
+	return plan, nil
+}
 
+func (h *LinuxUdevHandler) reloadRules(ctx context.Context) error {
+	// 重新加载并触发 udev 规则
+	for _, args := range reloadCommands {
+		output, err := utils.RunnerFromContext(ctx).Run(ctx, args[0], args[1:]...)
+		if err != nil {
+			return fmt.Errorf("failed to run %s: %v, output: %s", strings.Join(args, " "), err, output)
+		}
+	}
 	return nil
 }
 
-func (h *LinuxUdevHandler) generateUdevRules(rules []config.UdevRule) string {
+func (h *LinuxUdevHandler) generateUdevRules(rules []UdevRule) string {
 	var content strings.Builder
 	content.WriteString(config.CommentHeader)
 
 	for _, rule := range rules {
 		content.WriteString(fmt.Sprintf("SUBSYSTEM==\"%s\", ", rule.Subsystem))
-		for key, value := range rule.Attrs {
-			content.WriteString(fmt.Sprintf("ATTRS{%s}==\"%s\", ", key, value))
+		// 按属性名排序，保证生成的规则稳定
+		keys := make([]string, 0, len(rule.Attrs))
+		for key := range rule.Attrs {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			content.WriteString(fmt.Sprintf("ATTRS{%s}==\"%s\", ", key, rule.Attrs[key]))
 		}
 		content.WriteString(fmt.Sprintf("SYMLINK+=\"%s\"\n", rule.Symlink))
 	}
@@ -85,8 +159,8 @@ func (h *LinuxUdevHandler) generateUdevRules(rules []config.UdevRule) string {
 	return content.String()
 }
 
-func (h *LinuxUdevHandler) getCurrentUdevRules() (string, error) {
-	data, err := os.ReadFile("/etc/udev/rules.d/99-nix-operator.rules")
+func (h *LinuxUdevHandler) getCurrentUdevRules(fsys utils.FS) (string, error) {
+	data, err := fsys.ReadFile(rulesPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", nil // 文件不存在，返回空字符串
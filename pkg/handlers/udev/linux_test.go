@@ -0,0 +1,102 @@
+package udev
+
+import (
+	"slices"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+func TestRender(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc/udev/rules.d")
+	h := &LinuxUdevHandler{}
+	cfg := &config.ResourceConfig{
+		Kind: "UdevConfiguration",
+		Spec: []byte(`{"rules": [
+			{"name": "usb-serial", "subsystem": "tty", "attrs": {"idVendor": "0403", "idProduct": "6001"}, "symlink": "ttyUSB9"},
+			{"name": "modem", "subsystem": "tty", "attrs": {"serial": "A1B2C3"}, "symlink": "modem"}
+		]}`),
+	}
+
+	files, err := h.Render(testutil.Context(fsys, &testutil.FakeRunner{}), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Path != rulesPath {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	testutil.Golden(t, "rules", files[0].Content)
+}
+
+func TestReconcileReloadsRules(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc/udev/rules.d")
+	runner := &testutil.FakeRunner{}
+	ctx := testutil.Context(fsys, runner)
+	h := &LinuxUdevHandler{}
+	cfg := &config.ResourceConfig{
+		Kind: "UdevConfiguration",
+		Spec: []byte(`{"rules": [{"name": "usb-serial", "subsystem": "tty", "attrs": {"idVendor": "0403"}, "symlink": "ttyUSB9"}]}`),
+	}
+	reload := []string{"udevadm control --reload-rules", "udevadm trigger"}
+
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); !slices.Equal(got, reload) {
+		t.Errorf("got commands %q, want %q", got, reload)
+	}
+
+	// 规则未变化时不重新加载
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != len(reload) {
+		t.Errorf("unexpected commands on unchanged rules: %q", got[len(reload):])
+	}
+
+	if err := h.Cleanup(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat(rulesPath); err == nil {
+		t.Error("rules file not removed after cleanup")
+	}
+	if got := runner.Commands(); !slices.Equal(got, append(reload, reload...)) {
+		t.Errorf("got commands %q after cleanup", got)
+	}
+}
+
+func TestReconcileRetriesFailedReload(t *testing.T) {
+	fsys := testutil.NewRoot(t, "/etc/udev/rules.d")
+	runner := &testutil.FakeRunner{Results: map[string]testutil.FakeResult{
+		"udevadm control --reload-rules": {Output: "Failed to send reload request", ExitCode: 1},
+	}}
+	ctx := testutil.Context(fsys, runner)
+	h := &LinuxUdevHandler{}
+	cfg := &config.ResourceConfig{
+		Kind: "UdevConfiguration",
+		Spec: []byte(`{"rules": [{"name": "usb-serial", "subsystem": "tty", "attrs": {"idVendor": "0403"}, "symlink": "ttyUSB9"}]}`),
+	}
+
+	if _, err := h.Reconcile(ctx, cfg); err == nil {
+		t.Fatal("expected reload failure")
+	}
+
+	// 规则已经写入，重试时仍需重新加载
+	runner.Results = nil
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"udevadm control --reload-rules", "udevadm control --reload-rules", "udevadm trigger"}
+	if got := runner.Commands(); !slices.Equal(got, want) {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+
+	// 重新加载成功后不再重复执行
+	if _, err := h.Reconcile(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := runner.Commands(); len(got) != len(want) {
+		t.Errorf("unexpected commands after successful reload: %q", got[len(want):])
+	}
+}
@@ -0,0 +1,34 @@
+package udev
+
+import (
+	"maps"
+	"slices"
+
+	"go.xbrother.com/nix-operator/pkg/validation"
+)
+
+// Validate 校验 udev 规则的匹配条件和符号链接，避免生成无法解析的规则
+func (c *Config) Validate(path *validation.Path) validation.ErrorList {
+	var errs validation.ErrorList
+	for i, rule := range c.Rules {
+		rulePath := path.Child("rules").Index(i)
+		if rule.Subsystem == "" {
+			errs = append(errs, validation.Required(rulePath.Child("subsystem")))
+		} else {
+			errs = append(errs, validation.ValidateUdevValue(rulePath.Child("subsystem"), rule.Subsystem)...)
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(rule.Attrs)) {
+			attrPath := rulePath.Child("attrs").Key(key)
+			errs = append(errs, validation.ValidateUdevAttrKey(attrPath, key)...)
+			errs = append(errs, validation.ValidateUdevValue(attrPath, rule.Attrs[key])...)
+		}
+
+		if rule.Symlink == "" {
+			errs = append(errs, validation.Required(rulePath.Child("symlink")))
+		} else {
+			errs = append(errs, validation.ValidateUdevValue(rulePath.Child("symlink"), rule.Symlink)...)
+		}
+	}
+	return errs
+}
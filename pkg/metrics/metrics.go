@@ -0,0 +1,117 @@
+// Package metrics 定义 nix-operator 导出的 Prometheus 指标
+package metrics
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+)
+
+const namespace = "nix_operator"
+
+// phases 是资源可能处于的阶段，每个资源在当前阶段上取值为 1，其他阶段为 0
+var phases = []string{config.PhasePending, config.PhaseReady, config.PhaseFailed, config.PhaseDeleted}
+
+var (
+	// Registry 包含所有 nix-operator 指标以及 Go 运行时和进程指标
+	Registry = prometheus.NewRegistry()
+
+	ReconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "Number of resource reconciles by kind and resulting phase.",
+	}, []string{"kind", "result"})
+
+	ReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of resource reconciles by kind and resulting phase.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300},
+	}, []string{"kind", "result"})
+
+	ResourcePhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "resource_phase",
+		Help:      "Current phase of each resource, 1 for the current phase and 0 for the others.",
+	}, []string{"kind", "name", "phase"})
+
+	DriftDetections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "drift_detections_total",
+		Help:      "Number of drift checks that found generated files modified outside the operator.",
+	}, []string{"kind"})
+
+	CommandExecutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "command_executions_total",
+		Help:      "Number of external commands executed by handlers.",
+	}, []string{"kind", "command"})
+
+	CommandFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "command_failures_total",
+		Help:      "Number of external commands executed by handlers that failed or exited non-zero.",
+	}, []string{"kind", "command"})
+
+	FileEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fsnotify_events_total",
+		Help:      "Number of file system events received from the configuration directory by operation.",
+	}, []string{"op"})
+
+	// TODO: 串口透传会话的字节数和连接数指标推迟到透传功能（serial.configureTransparent）实现时添加
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ReconcileTotal,
+		ReconcileDuration,
+		ResourcePhase,
+		DriftDetections,
+		CommandExecutions,
+		CommandFailures,
+		FileEvents,
+	)
+}
+
+// Handler 返回以 Prometheus 文本格式输出指标的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// SetPhase 记录资源的当前阶段
+func SetPhase(kind, name, phase string) {
+	for _, p := range phases {
+		value := 0.0
+		if p == phase {
+			value = 1
+		}
+		ResourcePhase.WithLabelValues(kind, name, p).Set(value)
+	}
+}
+
+// DeleteResource 删除已删除资源的阶段指标
+func DeleteResource(kind, name string) {
+	ResourcePhase.DeletePartialMatch(prometheus.Labels{"kind": kind, "name": name})
+}
+
+// ObserveCommands 统计一次调谐或清理中执行的命令，命令以可执行文件名区分
+func ObserveCommands(kind string, records []config.CommandRecord) {
+	for _, record := range records {
+		if len(record.Command) == 0 {
+			continue
+		}
+		command := filepath.Base(record.Command[0])
+		CommandExecutions.WithLabelValues(kind, command).Inc()
+		if record.Error != "" {
+			CommandFailures.WithLabelValues(kind, command).Inc()
+		}
+	}
+}
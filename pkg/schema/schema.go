@@ -0,0 +1,206 @@
+// Package schema 根据资源的 spec 类型生成 JSON Schema，用于前端表单生成
+//
+// 字段的描述和界面提示来自结构体标签：
+//
+//	zh、en       中文、英文描述
+//	placeholder  输入提示，生成 ui:placeholder
+//	ui           界面控件，生成 ui:widget，如 select、textarea、updown
+//	enum         以逗号分隔的可选值
+//	format       字符串格式，如 ipv4、ipv6、hostname
+//	pattern      正则表达式
+//	minimum、maximum  数值范围
+//	default      默认值
+//	required     为 true 时字段必填
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.xbrother.com/nix-operator/pkg/controller"
+)
+
+// Draft 是生成的 JSON Schema 版本
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// 描述使用的语言
+const (
+	LangZH = "zh"
+	LangEN = "en"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// Schema 是 JSON Schema 的一个节点，ui: 开头的字段是表单界面提示
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Default              any                `json:"default,omitempty"`
+
+	UIWidget      string   `json:"ui:widget,omitempty"`
+	UIPlaceholder string   `json:"ui:placeholder,omitempty"`
+	UIOrder       []string `json:"ui:order,omitempty"` // 表单中字段的显示顺序
+}
+
+// Generate 生成资源类型 kind 的 spec 的 JSON Schema，spec 是指向 spec 零值的指针
+// lang 指定描述使用的语言，对应语言的描述缺失时使用另一种语言
+func Generate(kind string, spec any, lang string) *Schema {
+	s := forType(reflect.TypeOf(spec), lang)
+	s.Schema = Draft
+	s.ID = kind
+	s.Title = kind
+	return s
+}
+
+func forType(t reflect.Type, lang string) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// 未类型化的字段接受任意值
+	if t == rawMessageType {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{
+			Type:       "object",
+			Properties: make(map[string]*Schema),
+			// 控制器拒绝未定义的字段
+			AdditionalProperties: false,
+		}
+		addFields(s, t, lang)
+		return s
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: forType(t.Elem(), lang)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: forType(t.Elem(), lang)}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	return &Schema{}
+}
+
+// addFields 将结构体的字段加入对象 schema，匿名嵌入结构体的字段被展开
+func addFields(s *Schema, t reflect.Type, lang string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			addFields(s, field.Type, lang)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := forType(field.Type, lang)
+		applyTags(prop, field.Tag, lang)
+		s.Properties[name] = prop
+		s.UIOrder = append(s.UIOrder, name)
+		if field.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func applyTags(s *Schema, tag reflect.StructTag, lang string) {
+	s.Description = description(tag, lang)
+	s.UIWidget = tag.Get("ui")
+	s.UIPlaceholder = tag.Get("placeholder")
+
+	// 数组的取值约束作用于元素
+	target := s
+	if s.Type == "array" && s.Items != nil {
+		target = s.Items
+	}
+	target.Format = tag.Get("format")
+	target.Pattern = tag.Get("pattern")
+	if enum := tag.Get("enum"); enum != "" {
+		for _, value := range strings.Split(enum, ",") {
+			target.Enum = append(target.Enum, parseValue(target.Type, value))
+		}
+		if s.UIWidget == "" && target == s {
+			s.UIWidget = "select"
+		}
+	}
+	if v, err := strconv.ParseFloat(tag.Get("minimum"), 64); err == nil {
+		target.Minimum = &v
+	}
+	if v, err := strconv.ParseFloat(tag.Get("maximum"), 64); err == nil {
+		target.Maximum = &v
+	}
+	if value, ok := tag.Lookup("default"); ok {
+		s.Default = parseValue(s.Type, value)
+	}
+}
+
+func description(tag reflect.StructTag, lang string) string {
+	zh, en := tag.Get(LangZH), tag.Get(LangEN)
+	if lang == LangEN {
+		zh, en = en, zh
+	}
+	if zh != "" {
+		return zh
+	}
+	return en
+}
+
+// parseValue 按 schema 类型解析标签中的值
+func parseValue(typ, value string) any {
+	switch typ {
+	case "integer", "number", "boolean":
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// ForKind 生成已注册资源类型的 JSON Schema，类型未注册 spec 时 ok 为 false
+func ForKind(kind, lang string) (s *Schema, ok bool) {
+	spec, ok := controller.NewSpec(kind)
+	if !ok {
+		return nil, false
+	}
+	return Generate(kind, spec, lang), true
+}
+
+// All 生成所有已注册资源类型的 JSON Schema，键为资源类型
+func All(lang string) map[string]*Schema {
+	schemas := make(map[string]*Schema)
+	for _, kind := range controller.SpecKinds() {
+		schemas[kind], _ = ForKind(kind, lang)
+	}
+	return schemas
+}
@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/controller"
+	_ "go.xbrother.com/nix-operator/pkg/handlers/hosts"
+	_ "go.xbrother.com/nix-operator/pkg/handlers/network"
+	_ "go.xbrother.com/nix-operator/pkg/handlers/serial"
+	_ "go.xbrother.com/nix-operator/pkg/handlers/time"
+	_ "go.xbrother.com/nix-operator/pkg/handlers/udev"
+	"go.xbrother.com/nix-operator/pkg/testutil"
+)
+
+type testBase struct {
+	Name string `json:"name" required:"true" zh:"名称" en:"Name"`
+}
+
+type testSpec struct {
+	testBase
+	Mode    string            `json:"mode" enum:"a,b" default:"a" zh:"模式"`
+	Ports   []int             `json:"ports" minimum:"1" maximum:"65535" en:"Ports"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Ignored string            `json:"-"`
+	hidden  string
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate("Test", &testSpec{}, LangEN)
+
+	if !reflect.DeepEqual(s.UIOrder, []string{"name", "mode", "ports", "labels"}) {
+		t.Errorf("got order %v", s.UIOrder)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("got required %v", s.Required)
+	}
+	if got := s.Properties["name"].Description; got != "Name" {
+		t.Errorf("got name description %q", got)
+	}
+	// 缺少英文描述时使用中文描述
+	if got := s.Properties["mode"].Description; got != "模式" {
+		t.Errorf("got mode description %q", got)
+	}
+	mode := s.Properties["mode"]
+	if !reflect.DeepEqual(mode.Enum, []any{"a", "b"}) || mode.Default != "a" || mode.UIWidget != "select" {
+		t.Errorf("got mode %+v", mode)
+	}
+	ports := s.Properties["ports"]
+	if ports.Items.Minimum == nil || *ports.Items.Minimum != 1 || ports.Items.Maximum == nil || *ports.Items.Maximum != 65535 {
+		t.Errorf("got ports %+v", ports.Items)
+	}
+	if _, ok := s.Properties["labels"].AdditionalProperties.(*Schema); !ok {
+		t.Errorf("got labels %+v", s.Properties["labels"])
+	}
+	if s.AdditionalProperties != false {
+		t.Errorf("got additionalProperties %v", s.AdditionalProperties)
+	}
+}
+
+func TestKinds(t *testing.T) {
+	want := []string{"HostsConfiguration", "NetworkConfiguration", "SerialConfiguration", "TimeConfiguration", "UdevConfiguration"}
+	if got := controller.SpecKinds(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got kinds %v, want %v", got, want)
+	}
+
+	for _, kind := range want {
+		for _, lang := range []string{LangZH, LangEN} {
+			t.Run(kind+"/"+lang, func(t *testing.T) {
+				s, ok := ForKind(kind, lang)
+				if !ok {
+					t.Fatal("schema not found")
+				}
+				got, err := json.MarshalIndent(s, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				testutil.Golden(t, kind+"."+lang, append(got, '\n'))
+			})
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeResult 是 FakeRunner 对一条命令返回的结果
+type FakeResult struct {
+	Output   string
+	ExitCode int
+}
+
+// FakeRunner 记录处理器执行的命令而不实际执行
+// 未在 Results 中指定的命令成功退出且没有输出
+type FakeRunner struct {
+	// Results 的 key 是以空格连接的完整命令行
+	Results map[string]FakeResult
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func (r *FakeRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	command := append([]string{name}, args...)
+
+	r.mu.Lock()
+	r.commands = append(r.commands, command)
+	r.mu.Unlock()
+
+	result := r.Results[strings.Join(command, " ")]
+	if result.ExitCode != 0 {
+		return []byte(result.Output), &ExitError{Code: result.ExitCode}
+	}
+	return []byte(result.Output), nil
+}
+
+// Commands 返回按执行顺序记录的命令行
+func (r *FakeRunner) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := make([]string, len(r.commands))
+	for i, command := range r.commands {
+		lines[i] = strings.Join(command, " ")
+	}
+	return lines
+}
+
+// ExitError 模拟以非零状态退出的命令
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
@@ -0,0 +1,67 @@
+// Package testutil 提供处理器测试使用的临时根文件系统、命令执行器替身和黄金文件比较
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/utils"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the actual output")
+
+// NewRoot 在临时目录中创建根文件系统，并在其中创建给定的目录
+func NewRoot(t *testing.T, dirs ...string) *utils.RootFS {
+	t.Helper()
+	fsys := utils.NewRootFS(t.TempDir())
+	for _, dir := range dirs {
+		if err := os.MkdirAll(fsys.Path(dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fsys
+}
+
+// WriteFile 在根文件系统中写入文件，父目录不存在时自动创建
+func WriteFile(t *testing.T, fsys utils.FS, name string, content string) {
+	t.Helper()
+	path := fsys.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Context 返回使用 fsys 作为根文件系统、runner 执行命令的 context
+func Context(fsys utils.FS, runner utils.Runner) context.Context {
+	ctx := utils.WithFS(context.Background(), fsys)
+	return utils.WithRunner(ctx, runner)
+}
+
+// Golden 比较 got 与 testdata/<name>.golden，使用 go test -update 重新生成黄金文件
+func Golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("output differs from %s:\n%s", path, utils.UnifiedDiff(path, "got", want, got))
+	}
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext 是统一差异格式中每个变更块前后保留的上下文行数
+const diffContext = 3
+
+// UnifiedDiff 生成从 a 到 b 的统一差异格式文本，内容相同时返回空字符串
+// fromName、toName 分别作为 --- 和 +++ 行的文件名
+func UnifiedDiff(fromName, toName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	from := splitLines(string(a))
+	to := splitLines(string(b))
+	ops := diffLines(from, to)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// 将编辑脚本按上下文切分为变更块
+	for start := 0; start < len(ops); {
+		// 跳到下一个变更
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		first := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// 连续相同的行超过两倍上下文时结束当前块
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+
+		writeHunk(&out, ops[first:end])
+		start = end
+	}
+
+	return out.String()
+}
+
+type diffOp struct {
+	kind   byte // ' '、'-' 或 '+'
+	line   string
+	fromNo int // 在 a 中的行号（从 1 开始）
+	toNo   int // 在 b 中的行号（从 1 开始）
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp) {
+	var fromStart, toStart, fromCount, toCount int
+	for _, op := range ops {
+		if op.kind != '+' {
+			if fromCount == 0 {
+				fromStart = op.fromNo
+			}
+			fromCount++
+		}
+		if op.kind != '-' {
+			if toCount == 0 {
+				toStart = op.toNo
+			}
+			toCount++
+		}
+	}
+	// 空范围时起始行号表示插入/删除位置之前的行
+	if fromCount == 0 {
+		fromStart = ops[0].fromNo - 1
+	}
+	if toCount == 0 {
+		toStart = ops[0].toNo - 1
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+	for _, op := range ops {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines 基于最长公共子序列计算逐行编辑脚本
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', line: a[i], fromNo: i + 1, toNo: j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: a[i], fromNo: i + 1, toNo: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: b[j], fromNo: i + 1, toNo: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines 按行切分文本，每行保留末尾的换行符
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"go.xbrother.com/nix-operator/pkg/config"
 )
 
 // AtomicWriteFile 原子性地写入文件
@@ -50,3 +53,141 @@ func AtomicWriteFile(content []byte, filename string, perm os.FileMode) error {
 
 	return nil
 }
+
+// BackupDir 是目标系统中保存原文件备份的目录，备份按原文件的路径保存在该目录下，
+// 不会被 interfaces.d、NetworkManager 等按目录加载配置的程序当作配置读取
+const BackupDir = "/var/lib/nix-operator/backup"
+
+// legacyBackupSuffix 是旧版本保存在原文件旁的备份文件后缀
+const legacyBackupSuffix = ".nix-operator.bak"
+
+// IsGenerated 判断文件内容是否由 nix-operator 生成
+func IsGenerated(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(config.CommentHeader))
+}
+
+// BackupPath 返回文件的备份路径
+func BackupPath(filename string) string {
+	return filepath.Join(BackupDir, filepath.Clean(filename))
+}
+
+// BackupFile 在首次覆盖文件前备份原文件
+// 文件不存在、已有备份或文件由 nix-operator 生成时不做任何操作
+func BackupFile(fsys FS, filename string) error {
+	backup := BackupPath(filename)
+	if _, err := fsys.Stat(backup); err == nil {
+		return nil
+	}
+	if moved, err := migrateBackup(fsys, filename); err != nil || moved {
+		return err
+	}
+
+	content, err := fsys.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %v", filename, err)
+	}
+	if IsGenerated(content) {
+		return nil
+	}
+
+	info, err := fsys.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return writeBackup(fsys, backup, content, info.Mode().Perm())
+}
+
+func writeBackup(fsys FS, backup string, content []byte, perm os.FileMode) error {
+	if err := fsys.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	if err := fsys.WriteFile(backup, content, perm); err != nil {
+		return fmt.Errorf("failed to write backup %s: %v", backup, err)
+	}
+	return nil
+}
+
+// migrateBackup 将旧版本保存在原文件旁的备份移动到 BackupDir，返回是否存在旧备份
+func migrateBackup(fsys FS, filename string) (bool, error) {
+	legacy := filename + legacyBackupSuffix
+	content, err := fsys.ReadFile(legacy)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read %s: %v", legacy, err)
+	}
+	info, err := fsys.Stat(legacy)
+	if err != nil {
+		return false, err
+	}
+	if err := writeBackup(fsys, BackupPath(filename), content, info.Mode().Perm()); err != nil {
+		return false, err
+	}
+	if err := fsys.Remove(legacy); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %v", legacy, err)
+	}
+	return true, nil
+}
+
+// RestoreFile 使用 BackupFile 保留的备份恢复原文件
+// 返回值表示是否存在备份并完成了恢复
+func RestoreFile(fsys FS, filename string) (bool, error) {
+	if _, err := migrateBackup(fsys, filename); err != nil {
+		return false, err
+	}
+
+	// 备份目录与原文件可能不在同一个文件系统上，复制后再删除备份
+	backup := BackupPath(filename)
+	content, err := fsys.ReadFile(backup)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	info, err := fsys.Stat(backup)
+	if err != nil {
+		return false, err
+	}
+	if err := fsys.WriteFile(filename, content, info.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to restore %s: %v", filename, err)
+	}
+	if err := fsys.Remove(backup); err != nil {
+		return false, fmt.Errorf("failed to remove backup %s: %v", backup, err)
+	}
+	return true, nil
+}
+
+// RemoveGeneratedFile 删除由 nix-operator 生成的文件，其他文件保持不变
+// 返回值表示文件是否被删除
+func RemoveGeneratedFile(fsys FS, filename string) (bool, error) {
+	content, err := fsys.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !IsGenerated(content) {
+		return false, nil
+	}
+
+	if err := fsys.Remove(filename); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %v", filename, err)
+	}
+	return true, nil
+}
+
+// RevertFile 撤销 nix-operator 对文件的修改：优先恢复备份，否则删除生成的文件
+// 返回值表示文件是否发生了变化
+func RevertFile(fsys FS, filename string) (bool, error) {
+	restored, err := RestoreFile(fsys, filename)
+	if err != nil || restored {
+		return restored, err
+	}
+	return RemoveGeneratedFile(fsys, filename)
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+)
+
+const original = "127.0.0.1 localhost\n"
+
+func newRootFS(t *testing.T) *RootFS {
+	fsys := NewRootFS(t.TempDir())
+	if err := fsys.MkdirAll("/etc/network/interfaces.d", 0755); err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
+
+func readFile(t *testing.T, fsys FS, name string) string {
+	t.Helper()
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	fsys := newRootFS(t)
+	name := "/etc/network/interfaces.d/eth0"
+	if err := fsys.WriteFile(name, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BackupFile(fsys, name); err != nil {
+		t.Fatal(err)
+	}
+	// 备份不能留在按目录加载的配置目录中
+	entries, err := fsys.ReadDir(filepath.Dir(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("backup written next to the original file: %v", entries)
+	}
+	if got := readFile(t, fsys, BackupPath(name)); got != original {
+		t.Errorf("got backup %q, want %q", got, original)
+	}
+
+	// 已有备份时不覆盖
+	if err := fsys.WriteFile(name, []byte(config.CommentHeader+"auto eth0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := BackupFile(fsys, name); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, BackupPath(name)); got != original {
+		t.Errorf("backup overwritten, got %q", got)
+	}
+
+	changed, err := RevertFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("expected the original file to be restored")
+	}
+	if got := readFile(t, fsys, name); got != original {
+		t.Errorf("got %q after restore, want %q", got, original)
+	}
+	if info, err := fsys.Stat(name); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, err %v, want 0600", info.Mode().Perm(), err)
+	}
+	if _, err := fsys.Stat(BackupPath(name)); !os.IsNotExist(err) {
+		t.Errorf("backup not removed after restore: %v", err)
+	}
+}
+
+func TestBackupSkipsGeneratedFile(t *testing.T) {
+	fsys := newRootFS(t)
+	name := "/etc/network/interfaces.d/eth0"
+	if err := fsys.WriteFile(name, []byte(config.CommentHeader+"auto eth0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BackupFile(fsys, name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat(BackupPath(name)); !os.IsNotExist(err) {
+		t.Errorf("generated file was backed up: %v", err)
+	}
+
+	// 没有备份时删除生成的文件
+	changed, err := RevertFile(fsys, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("expected the generated file to be removed")
+	}
+	if _, err := fsys.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("generated file not removed: %v", err)
+	}
+
+	// 非生成的文件保持不变
+	if err := fsys.WriteFile(name, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := RevertFile(fsys, name); err != nil || changed {
+		t.Errorf("got changed %v, err %v, want user file kept", changed, err)
+	}
+}
+
+func TestRestoreLegacyBackup(t *testing.T) {
+	fsys := newRootFS(t)
+	name := "/etc/network/interfaces.d/eth0"
+	if err := fsys.WriteFile(name, []byte(config.CommentHeader+"auto eth0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile(name+legacyBackupSuffix, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本的备份在下次写入前被移出配置目录
+	if err := BackupFile(fsys, name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat(name + legacyBackupSuffix); !os.IsNotExist(err) {
+		t.Errorf("legacy backup not moved: %v", err)
+	}
+
+	if _, err := RestoreFile(fsys, name); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, name); got != original {
+		t.Errorf("got %q after restore, want %q", got, original)
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// FS 是处理器访问宿主文件系统的接口
+// 所有路径都是目标系统中的绝对路径，如 /etc/hosts，由实现映射到实际位置
+type FS interface {
+	// Path 返回路径在当前进程中实际对应的位置
+	Path(name string) string
+	ReadFile(name string) ([]byte, error)
+	// WriteFile 原子性地写入文件
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Stat(name string) (os.FileInfo, error)
+	Readlink(name string) (string, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	MkdirAll(name string, perm os.FileMode) error
+}
+
+// RootFS 将目标系统的路径映射到 root 目录下
+type RootFS struct {
+	root string
+}
+
+// NewRootFS 创建以 root 为根目录的文件系统，root 为 "/" 时即宿主系统本身
+func NewRootFS(root string) *RootFS {
+	return &RootFS{root: root}
+}
+
+// Root 返回根目录
+func (r *RootFS) Root() string {
+	return r.root
+}
+
+func (r *RootFS) Path(name string) string {
+	return filepath.Join(r.root, name)
+}
+
+func (r *RootFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(r.Path(name))
+}
+
+func (r *RootFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return AtomicWriteFile(data, r.Path(name), perm)
+}
+
+func (r *RootFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(r.Path(name))
+}
+
+func (r *RootFS) Readlink(name string) (string, error) {
+	return os.Readlink(r.Path(name))
+}
+
+func (r *RootFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(r.Path(name))
+}
+
+func (r *RootFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(r.Path(name), flag, perm)
+}
+
+func (r *RootFS) Rename(oldname, newname string) error {
+	return os.Rename(r.Path(oldname), r.Path(newname))
+}
+
+func (r *RootFS) Remove(name string) error {
+	return os.Remove(r.Path(name))
+}
+
+func (r *RootFS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(r.Path(name), perm)
+}
+
+// HostFS 是宿主系统的根文件系统
+var HostFS FS = NewRootFS("/")
+
+type fsKey struct{}
+
+// WithFS 返回携带文件系统的 context，处理器通过 FSFromContext 获取
+func WithFS(ctx context.Context, fsys FS) context.Context {
+	return context.WithValue(ctx, fsKey{}, fsys)
+}
+
+// FSFromContext 返回 context 中的文件系统，未设置时返回 HostFS
+func FSFromContext(ctx context.Context) FS {
+	if fsys, ok := ctx.Value(fsKey{}).(FS); ok {
+		return fsys
+	}
+	return HostFS
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Link 是网卡的实时状态
+type Link struct {
+	Name      string
+	Index     int
+	MTU       int
+	MAC       string
+	Up        bool   // 管理状态，即接口是否被启用
+	OperState string // 内核报告的运行状态，如 up、down、lowerlayerdown
+	Carrier   bool   // 物理链路是否连通
+	Loopback  bool
+	Addresses []LinkAddress
+	Gateway4  string // IPv4 默认路由的网关，多条时取优先级最高的
+	Gateway6  string
+}
+
+// LinkAddress 是网卡上的一个地址
+type LinkAddress struct {
+	Prefix netip.Prefix
+	// Dynamic 表示地址有有效期，由 DHCP 或 SLAAC 分配，否则是静态配置的地址
+	Dynamic bool
+}
+
+// ListLinks 通过 netlink 读取本机所有网卡的地址、默认网关和链路状态
+func ListLinks() ([]Link, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %v", err)
+	}
+	gateways4, err := defaultGateways(netlink.FAMILY_V4)
+	if err != nil {
+		return nil, err
+	}
+	gateways6, err := defaultGateways(netlink.FAMILY_V6)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Link
+	for _, link := range links {
+		attrs := link.Attrs()
+		l := Link{
+			Name:      attrs.Name,
+			Index:     attrs.Index,
+			MTU:       attrs.MTU,
+			MAC:       attrs.HardwareAddr.String(),
+			Up:        attrs.Flags&net.FlagUp != 0,
+			OperState: attrs.OperState.String(),
+			Carrier:   attrs.RawFlags&unix.IFF_LOWER_UP != 0,
+			Loopback:  attrs.Flags&net.FlagLoopback != 0,
+			Gateway4:  gateways4[attrs.Index],
+			Gateway6:  gateways6[attrs.Index],
+		}
+
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list addresses of %s: %v", attrs.Name, err)
+		}
+		for _, addr := range addrs {
+			ip, ok := netip.AddrFromSlice(addr.IP)
+			if !ok {
+				continue
+			}
+			ones, _ := addr.Mask.Size()
+			l.Addresses = append(l.Addresses, LinkAddress{
+				Prefix:  netip.PrefixFrom(ip.Unmap(), ones),
+				Dynamic: addr.Flags&unix.IFA_F_PERMANENT == 0,
+			})
+		}
+		result = append(result, l)
+	}
+	return result, nil
+}
+
+// defaultGateways 返回每个网卡的默认路由网关，键为网卡索引
+func defaultGateways(family int) (map[int]string, error) {
+	routes, err := netlink.RouteList(nil, family)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %v", err)
+	}
+
+	gateways := make(map[int]string)
+	priorities := make(map[int]int)
+	for _, route := range routes {
+		if route.Gw == nil || (route.Dst != nil && !isDefaultRoute(route.Dst)) {
+			continue
+		}
+		if p, ok := priorities[route.LinkIndex]; ok && p <= route.Priority {
+			continue
+		}
+		gateways[route.LinkIndex] = route.Gw.String()
+		priorities[route.LinkIndex] = route.Priority
+	}
+	return gateways, nil
+}
+
+func isDefaultRoute(dst *net.IPNet) bool {
+	ones, _ := dst.Mask.Size()
+	return ones == 0
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogFormatJSON, "warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithLogger(context.Background(), logger.With("kind", "TimeConfiguration"))
+	LoggerFromContext(ctx).Info("ignored")
+	LoggerFromContext(ctx).Warn("Command finished", "exitCode", 1)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("got %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "Command finished" || entry["kind"] != "TimeConfiguration" || entry["exitCode"] != 1.0 {
+		t.Errorf("got entry %v", entry)
+	}
+
+	if _, err := NewLogger(&buf, "xml", "info"); err == nil {
+		t.Error("expected error for invalid format")
+	}
+	if _, err := NewLogger(&buf, LogFormatText, "verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
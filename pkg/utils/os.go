@@ -8,8 +8,8 @@ import (
 )
 
 type NodeSelector struct {
-	MACAddress string `yaml:"macAddress"`
-	Hostname   string `yaml:"hostname"`
+	MACAddress string `json:"macAddress" yaml:"macAddress" zh:"设备 MAC 地址" en:"Device MAC address" placeholder:"00:11:22:33:44:55"`
+	Hostname   string `json:"hostname" yaml:"hostname" format:"hostname" zh:"设备主机名" en:"Device hostname"`
 }
 
 func MatchNodeSelector(selector NodeSelector) (bool, error) {
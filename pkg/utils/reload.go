@@ -0,0 +1,47 @@
+package utils
+
+import "sync"
+
+// PendingReloads 记录配置已经写入但尚未成功重新加载的目标
+// 重新加载失败后重试时配置内容已经一致，处理器据此仍然执行重新加载
+// 零值可以直接使用
+type PendingReloads struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+// Mark 记录 key 对应的配置需要重新加载
+func (p *PendingReloads) Mark(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[string]bool)
+	}
+	p.keys[key] = true
+}
+
+// Pending 返回 key 对应的配置是否需要重新加载
+func (p *PendingReloads) Pending(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keys[key]
+}
+
+// Done 在重新加载成功后清除 key 的记录
+func (p *PendingReloads) Done(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, key)
+}
+
+// Reload 在 key 对应的配置需要重新加载时执行 reload，成功后清除记录
+func (p *PendingReloads) Reload(key string, reload func() error) error {
+	if !p.Pending(key) {
+		return nil
+	}
+	if err := reload(); err != nil {
+		return err
+	}
+	p.Done(key)
+	return nil
+}
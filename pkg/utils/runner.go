@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go.xbrother.com/nix-operator/pkg/config"
+)
+
+// Runner 执行外部命令，处理器通过 RunnerFromContext 获取
+type Runner interface {
+	// Run 执行命令并返回合并的标准输出和标准错误
+	// 命令以非零状态退出时，返回的错误实现 ExitCode() int
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner 在宿主系统中执行命令
+type ExecRunner struct{}
+
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// HostRunner 是默认的命令执行器
+var HostRunner Runner = ExecRunner{}
+
+// ErrDryRun 是 DryRunner 代替命令的执行结果返回的错误
+var ErrDryRun = errors.New("command not executed in dry run")
+
+// DryRunner 只记录命令而不执行，用于在不修改系统的情况下预览变更
+type DryRunner struct {
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func (r *DryRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, append([]string{name}, args...))
+	return nil, ErrDryRun
+}
+
+// Commands 返回被跳过的命令
+func (r *DryRunner) Commands() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.commands...)
+}
+
+// maxRecordedOutput 是每条命令记录中保留的最大输出字节数
+const maxRecordedOutput = 4096
+
+// Recorder 包装 Runner，记录执行的每一条命令及其结果
+type Recorder struct {
+	runner Runner
+
+	mu      sync.Mutex
+	records []config.CommandRecord
+}
+
+func NewRecorder(runner Runner) *Recorder {
+	return &Recorder{runner: runner}
+}
+
+func (r *Recorder) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	output, err := r.runner.Run(ctx, name, args...)
+
+	record := config.CommandRecord{
+		Command:  append([]string{name}, args...),
+		ExitCode: ExitCode(err),
+		Output:   truncateOutput(output),
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	LoggerFromContext(ctx).Info("Command finished",
+		"command", strings.Join(record.Command, " "), "exitCode", record.ExitCode, "duration", time.Since(start))
+
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+	return output, err
+}
+
+// Records 返回已记录的命令
+func (r *Recorder) Records() []config.CommandRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]config.CommandRecord(nil), r.records...)
+}
+
+// ExitCode 返回 Runner 错误对应的退出码，err 为 nil 时返回 0，命令无法启动时返回 -1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func truncateOutput(output []byte) string {
+	if len(output) <= maxRecordedOutput {
+		return string(output)
+	}
+	return string(output[:maxRecordedOutput]) + "\n... (truncated)"
+}
+
+type runnerKey struct{}
+
+// WithRunner 返回携带命令执行器的 context
+func WithRunner(ctx context.Context, runner Runner) context.Context {
+	return context.WithValue(ctx, runnerKey{}, runner)
+}
+
+// RunnerFromContext 返回 context 中的命令执行器，未设置时返回 HostRunner
+func RunnerFromContext(ctx context.Context) Runner {
+	if runner, ok := ctx.Value(runnerKey{}).(Runner); ok {
+		return runner
+	}
+	return HostRunner
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifier 按 sd_notify 协议通过 NOTIFY_SOCKET 向 systemd 报告服务状态
+// nil 表示不在 systemd 的 Type=notify 服务中运行，此时所有调用都不发送
+type Notifier struct {
+	addr *net.UnixAddr
+}
+
+// NewNotifier 创建向 socket 发送通知的 Notifier，以 @ 开头的是抽象命名空间中的 socket
+func NewNotifier(socket string) *Notifier {
+	if socket == "" {
+		return nil
+	}
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	return &Notifier{addr: &net.UnixAddr{Name: socket, Net: "unixgram"}}
+}
+
+// NotifierFromEnv 按 systemd 设置的 NOTIFY_SOCKET 环境变量创建 Notifier
+func NotifierFromEnv() *Notifier {
+	return NewNotifier(os.Getenv("NOTIFY_SOCKET"))
+}
+
+// Notify 发送换行分隔的状态，如 "READY=1"、"STATUS=..."、"WATCHDOG=1"
+func (n *Notifier) Notify(state ...string) error {
+	if n == nil {
+		return nil
+	}
+	conn, err := net.DialUnix("unixgram", nil, n.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to notify socket: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(strings.Join(state, "\n"))); err != nil {
+		return fmt.Errorf("failed to notify systemd: %v", err)
+	}
+	return nil
+}
+
+// WatchdogInterval 返回 systemd 要求的看门狗超时时间，未启用看门狗时返回 0
+// WATCHDOG_PID 存在时只有该进程需要发送 WATCHDOG=1
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNotifier(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := NewNotifier(socket).Notify("READY=1", "STATUS=2 resources: 2 Ready"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=2 resources: 2 Ready" {
+		t.Errorf("got notification %q", got)
+	}
+
+	// 不在 systemd 中运行时不发送
+	var notifier *Notifier
+	if err := notifier.Notify("READY=1"); err != nil {
+		t.Errorf("nil notifier: %v", err)
+	}
+	if NewNotifier("") != nil {
+		t.Error("notifier created without socket")
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := WatchdogInterval(); got != 30*time.Second {
+		t.Errorf("got interval %s, want 30s", got)
+	}
+	// 看门狗属于其他进程
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := WatchdogInterval(); got != 0 {
+		t.Errorf("got interval %s for another process", got)
+	}
+}
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Spec 是带有校验规则的类型化 spec
+type Spec interface {
+	// Validate 校验 spec 的取值，path 是 spec 在资源中的路径
+	Validate(path *Path) ErrorList
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// DecodeSpec 严格解析 raw 到 spec 并按 spec 的规则校验
+// 未定义的字段和类型不匹配的字段都会作为错误返回，而不是被忽略
+func DecodeSpec(raw json.RawMessage, spec Spec) ErrorList {
+	path := NewPath("spec")
+
+	var value any
+	if len(bytes.TrimSpace(raw)) > 0 {
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return ErrorList{{Field: path.String(), Message: err.Error()}}
+		}
+	}
+	if value == nil {
+		return ErrorList{Required(path)}
+	}
+
+	if errs := checkValue(path, value, reflect.TypeOf(spec)); len(errs) > 0 {
+		return errs
+	}
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return ErrorList{{Field: path.String(), Message: err.Error()}}
+	}
+	return spec.Validate(path)
+}
+
+// checkValue 按 JSON 解码规则检查 value 是否能解码为类型 t
+func checkValue(path *Path, value any, t reflect.Type) ErrorList {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || t == rawMessageType || t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	var errs ErrorList
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return ErrorList{TypeMismatch(path, "an object")}
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			field, ok := fields[key]
+			if !ok {
+				errs = append(errs, Unknown(path.Child(key), similarField(fields, key)))
+				continue
+			}
+			errs = append(errs, checkValue(path.Child(key), obj[key], field)...)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return ErrorList{TypeMismatch(path, "an array")}
+		}
+		for i, item := range items {
+			errs = append(errs, checkValue(path.Index(i), item, t.Elem())...)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return ErrorList{TypeMismatch(path, "an object")}
+		}
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			errs = append(errs, checkValue(path.Key(key), obj[key], t.Elem())...)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return ErrorList{TypeMismatch(path, "a string")}
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return ErrorList{TypeMismatch(path, "a boolean")}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return ErrorList{TypeMismatch(path, "an integer")}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) || n < 0 {
+			return ErrorList{TypeMismatch(path, "a non-negative integer")}
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			return ErrorList{TypeMismatch(path, "a number")}
+		}
+	}
+	return errs
+}
+
+// jsonFields 返回结构体中 JSON 字段名到字段类型的映射，包括匿名嵌入结构体的字段
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			maps.Copy(fields, jsonFields(field.Type))
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// similarField 返回与 key 仅大小写不同或互为前缀的字段名，用于提示拼写错误
+func similarField(fields map[string]reflect.Type, key string) string {
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		lowerName, lowerKey := strings.ToLower(name), strings.ToLower(key)
+		if strings.HasPrefix(lowerName, lowerKey) || strings.HasPrefix(lowerKey, lowerName) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testSpec struct {
+	Name    string            `json:"name"`
+	Port    int               `json:"port"`
+	Enabled bool              `json:"enabled"`
+	Items   []testItem        `json:"items"`
+	Labels  map[string]string `json:"labels"`
+	Nested  *testItem         `json:"nested,omitempty"`
+}
+
+type testItem struct {
+	Address string `json:"address"`
+}
+
+func (s *testSpec) Validate(path *Path) ErrorList {
+	var errs ErrorList
+	for i, item := range s.Items {
+		errs = append(errs, ValidateIP(path.Child("items").Index(i).Child("address"), item.Address)...)
+	}
+	return errs
+}
+
+func TestDecodeSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want ErrorList
+	}{
+		{
+			name: "valid",
+			spec: `{"name": "a", "port": 80, "enabled": true, "items": [{"address": "10.0.0.1"}], "labels": {"k": "v"}}`,
+		},
+		{
+			name: "missing spec",
+			spec: ``,
+			want: ErrorList{{Field: "spec", Message: "required value"}},
+		},
+		{
+			name: "unknown field with hint",
+			spec: `{"enable": true, "nested": {"adress": "x"}, "color": "red"}`,
+			want: ErrorList{
+				{Field: "spec.color", Message: "unknown field"},
+				{Field: "spec.enable", Message: `unknown field, did you mean "enabled"?`},
+				{Field: "spec.nested.adress", Message: "unknown field"},
+			},
+		},
+		{
+			name: "case mismatch",
+			spec: `{"Name": "a"}`,
+			want: ErrorList{{Field: "spec.Name", Message: `unknown field, did you mean "name"?`}},
+		},
+		{
+			name: "type mismatch",
+			spec: `{"port": "80", "enabled": 1, "items": [{"address": "10.0.0.1"}, {"address": 5}], "labels": {"k": 1}}`,
+			want: ErrorList{
+				{Field: "spec.enabled", Message: "must be a boolean"},
+				{Field: "spec.items[1].address", Message: "must be a string"},
+				{Field: "spec.labels[k]", Message: "must be a string"},
+				{Field: "spec.port", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "fractional integer",
+			spec: `{"port": 80.5}`,
+			want: ErrorList{{Field: "spec.port", Message: "must be an integer"}},
+		},
+		{
+			name: "rule",
+			spec: `{"items": [{"address": "10.0.0.1"}, {"address": "10.0.0.256"}]}`,
+			want: ErrorList{{Field: "spec.items[1].address", Message: `invalid value "10.0.0.256": must be an IP address`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DecodeSpec(json.RawMessage(tt.spec), &testSpec{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got errors:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	path := NewPath("spec").Child("field")
+	tests := []struct {
+		name  string
+		errs  ErrorList
+		valid bool
+	}{
+		{"ipv4 cidr", ValidateIPv4CIDR(path, "192.168.1.100/24"), true},
+		{"ipv4 cidr without prefix", ValidateIPv4CIDR(path, "192.168.1.100"), false},
+		{"ipv4 cidr with ipv6", ValidateIPv4CIDR(path, "2001:db8::1/64"), false},
+		{"ipv6 cidr", ValidateIPv6CIDR(path, "2001:db8::1/64"), true},
+		{"ipv6 with ipv4", ValidateIPv6(path, "10.0.0.1"), false},
+		{"mac", ValidateMAC(path, "00:11:22:33:44:55"), true},
+		{"mac invalid", ValidateMAC(path, "00:11:22:33:44"), false},
+		{"hostname", ValidateHostname(path, "host1.example.com"), true},
+		{"hostname invalid", ValidateHostname(path, "host_1"), false},
+		{"host ip", ValidateHost(path, "ntp.aliyun.com"), true},
+		{"host port", ValidateHostPort(path, "0.0.0.0:8080"), true},
+		{"host port out of range", ValidateHostPort(path, "0.0.0.0:70000"), false},
+		{"timezone", ValidateTimezone(path, "Asia/Shanghai"), true},
+		{"timezone unknown", ValidateTimezone(path, "Asia/Beijing"), false},
+		{"timezone local", ValidateTimezone(path, "Local"), false},
+		{"udev attr", ValidateUdevAttrKey(path, "idVendor"), true},
+		{"udev attr injection", ValidateUdevAttrKey(path, `idVendor}=="x", RUN+="/bin/sh`), false},
+		{"udev value quote", ValidateUdevValue(path, `0403", RUN+="/bin/sh`), false},
+		{"baud rate", ValidateBaudRate(path, 115200), true},
+		{"baud rate invalid", ValidateBaudRate(path, 115201), false},
+		{"one of", ValidateOneOf(path, "odd", "none", "even", "odd"), true},
+		{"range", ValidateRange(path, 9000, 68, 65535), true},
+		{"range invalid", ValidateRange(path, 10, 68, 65535), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if valid := len(tt.errs) == 0; valid != tt.valid {
+				t.Errorf("got valid=%v, want %v: %v", valid, tt.valid, tt.errs)
+			}
+			for _, err := range tt.errs {
+				if err.Field != "spec.field" {
+					t.Errorf("got field %q", err.Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package validation
+
+import (
+	"net"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	// 内置时区数据库，校验结果不依赖宿主系统是否安装了 tzdata
+	_ "time/tzdata"
+)
+
+// BaudRates 是串口支持的标准波特率
+var BaudRates = []int{
+	50, 75, 110, 134, 150, 200, 300, 600, 1200, 1800, 2400, 4800, 9600,
+	19200, 38400, 57600, 115200, 230400, 460800, 500000, 576000, 921600,
+	1000000, 1152000, 1500000, 2000000, 2500000, 3000000, 3500000, 4000000,
+}
+
+// ValidateIP 校验 IPv4 或 IPv6 地址
+func ValidateIP(path *Path, value string) ErrorList {
+	if _, err := netip.ParseAddr(value); err != nil {
+		return ErrorList{Invalid(path, value, "must be an IP address")}
+	}
+	return nil
+}
+
+// ValidateIPv4 校验 IPv4 地址
+func ValidateIPv4(path *Path, value string) ErrorList {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is4() {
+		return ErrorList{Invalid(path, value, "must be an IPv4 address")}
+	}
+	return nil
+}
+
+// ValidateIPv6 校验 IPv6 地址
+func ValidateIPv6(path *Path, value string) ErrorList {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || !addr.Is6() || addr.Is4In6() {
+		return ErrorList{Invalid(path, value, "must be an IPv6 address")}
+	}
+	return nil
+}
+
+// ValidateIPv4CIDR 校验带前缀长度的 IPv4 地址，如 192.168.1.100/24
+func ValidateIPv4CIDR(path *Path, value string) ErrorList {
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil || !prefix.Addr().Is4() {
+		return ErrorList{Invalid(path, value, "must be an IPv4 address with prefix length, e.g. 192.168.1.100/24")}
+	}
+	return nil
+}
+
+// ValidateIPv6CIDR 校验带前缀长度的 IPv6 地址，如 2001:db8::1/64
+func ValidateIPv6CIDR(path *Path, value string) ErrorList {
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil || !prefix.Addr().Is6() || prefix.Addr().Is4In6() {
+		return ErrorList{Invalid(path, value, "must be an IPv6 address with prefix length, e.g. 2001:db8::1/64")}
+	}
+	return nil
+}
+
+// ValidateMAC 校验 MAC 地址
+func ValidateMAC(path *Path, value string) ErrorList {
+	if _, err := net.ParseMAC(value); err != nil {
+		return ErrorList{Invalid(path, value, "must be a MAC address, e.g. 00:11:22:33:44:55")}
+	}
+	return nil
+}
+
+var hostnameLabel = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// ValidateHostname 校验 RFC 1123 主机名
+func ValidateHostname(path *Path, value string) ErrorList {
+	if len(value) > 253 {
+		return ErrorList{Invalid(path, value, "must be no more than 253 characters")}
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(value, "."), ".") {
+		if !hostnameLabel.MatchString(label) {
+			return ErrorList{Invalid(path, value, "must be a valid hostname")}
+		}
+	}
+	return nil
+}
+
+// ValidateHost 校验主机名或 IP 地址
+func ValidateHost(path *Path, value string) ErrorList {
+	if _, err := netip.ParseAddr(value); err == nil {
+		return nil
+	}
+	if errs := ValidateHostname(path, value); len(errs) > 0 {
+		return ErrorList{Invalid(path, value, "must be a hostname or IP address")}
+	}
+	return nil
+}
+
+// ValidateHostPort 校验监听地址，如 0.0.0.0:8080
+func ValidateHostPort(path *Path, value string) ErrorList {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return ErrorList{Invalid(path, value, "must be host:port")}
+	}
+	if host != "" && len(ValidateHost(path, host)) > 0 {
+		return ErrorList{Invalid(path, value, "must be host:port with a valid host")}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return ErrorList{Invalid(path, value, "port must be between 1 and 65535")}
+	}
+	return nil
+}
+
+// ValidateTimezone 校验 IANA 时区名称，如 Asia/Shanghai
+func ValidateTimezone(path *Path, value string) ErrorList {
+	if value == "" || value == "Local" || strings.HasPrefix(value, "/") || strings.Contains(value, "..") {
+		return ErrorList{Invalid(path, value, "must be an IANA time zone name, e.g. Asia/Shanghai")}
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return ErrorList{Invalid(path, value, "unknown time zone")}
+	}
+	return nil
+}
+
+var udevAttrKey = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-/]*$`)
+
+// ValidateUdevAttrKey 校验 udev 规则中的 sysfs 属性名，如 idVendor
+func ValidateUdevAttrKey(path *Path, key string) ErrorList {
+	if !udevAttrKey.MatchString(key) || strings.Contains(key, "..") {
+		return ErrorList{Invalid(path, key, "must be a sysfs attribute name")}
+	}
+	return nil
+}
+
+// ValidateUdevValue 校验 udev 规则中的匹配值，值会被写入双引号之间
+func ValidateUdevValue(path *Path, value string) ErrorList {
+	if strings.ContainsAny(value, "\"\n\\") {
+		return ErrorList{Invalid(path, value, "must not contain quotes, backslashes or newlines")}
+	}
+	return nil
+}
+
+// ValidateBaudRate 校验串口波特率
+func ValidateBaudRate(path *Path, value int) ErrorList {
+	if !slices.Contains(BaudRates, value) {
+		return ErrorList{Invalid(path, value, "must be a standard baud rate, e.g. 9600 or 115200")}
+	}
+	return nil
+}
+
+// ValidateOneOf 校验取值是否在允许的范围内
+func ValidateOneOf[T comparable](path *Path, value T, supported ...T) ErrorList {
+	if !slices.Contains(supported, value) {
+		return ErrorList{NotSupported(path, value, supported)}
+	}
+	return nil
+}
+
+// ValidateRange 校验整数是否在 [min, max] 范围内
+func ValidateRange(path *Path, value, min, max int) ErrorList {
+	if value < min || value > max {
+		return ErrorList{Invalid(path, value, "must be between "+strconv.Itoa(min)+" and "+strconv.Itoa(max))}
+	}
+	return nil
+}
+
+// ValidateNonNegative 校验整数不小于 0
+func ValidateNonNegative(path *Path, value int) ErrorList {
+	if value < 0 {
+		return ErrorList{Invalid(path, value, "must be greater than or equal to 0")}
+	}
+	return nil
+}
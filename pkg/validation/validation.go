@@ -0,0 +1,109 @@
+// Package validation 校验资源的 spec，错误带有精确的字段路径
+package validation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path 是字段在资源中的路径，如 spec.interfaces[0].ipAddress
+type Path struct {
+	parent *Path
+	name   string // 字段名，或 [0]、[key] 形式的下标
+}
+
+// NewPath 创建以 name 为根的路径
+func NewPath(name string) *Path {
+	return &Path{name: name}
+}
+
+// Child 返回子字段的路径
+func (p *Path) Child(name string) *Path {
+	return &Path{parent: p, name: name}
+}
+
+// Index 返回数组元素的路径
+func (p *Path) Index(i int) *Path {
+	return &Path{parent: p, name: "[" + strconv.Itoa(i) + "]"}
+}
+
+// Key 返回映射元素的路径
+func (p *Path) Key(key string) *Path {
+	return &Path{parent: p, name: "[" + key + "]"}
+}
+
+func (p *Path) String() string {
+	if p.parent == nil {
+		return p.name
+	}
+	parent := p.parent.String()
+	if strings.HasPrefix(p.name, "[") {
+		return parent + p.name
+	}
+	return parent + "." + p.name
+}
+
+// FieldError 是单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ErrorList 是校验错误列表
+type ErrorList []FieldError
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Required 表示缺少必填字段
+func Required(path *Path) FieldError {
+	return FieldError{Field: path.String(), Message: "required value"}
+}
+
+// Invalid 表示字段的值不合法
+func Invalid(path *Path, value any, detail string) FieldError {
+	return FieldError{Field: path.String(), Message: fmt.Sprintf("invalid value %s: %s", formatValue(value), detail)}
+}
+
+// NotSupported 表示字段的值不在允许的范围内
+func NotSupported[T any](path *Path, value T, supported []T) FieldError {
+	values := make([]string, len(supported))
+	for i, v := range supported {
+		values[i] = formatValue(v)
+	}
+	return FieldError{
+		Field:   path.String(),
+		Message: fmt.Sprintf("unsupported value %s: supported values are %s", formatValue(value), strings.Join(values, ", ")),
+	}
+}
+
+// Unknown 表示 spec 中出现了未定义的字段
+func Unknown(path *Path, hint string) FieldError {
+	message := "unknown field"
+	if hint != "" {
+		message += fmt.Sprintf(", did you mean %q?", hint)
+	}
+	return FieldError{Field: path.String(), Message: message}
+}
+
+// TypeMismatch 表示字段的类型不正确
+func TypeMismatch(path *Path, want string) FieldError {
+	return FieldError{Field: path.String(), Message: "must be " + want}
+}
+
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}